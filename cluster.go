@@ -1,6 +1,9 @@
 package cluster
 
 import (
+	"errors"
+	"iter"
+	"maps"
 	"math"
 
 	"github.com/MadAppGang/kdbush"
@@ -10,6 +13,12 @@ import (
 //That Zoom level indicate impossible large zoom level (Cluster's max is 21)
 const InfinityZoomLevel = 100
 
+// ErrClusterNotFound is returned when there is no cluster with requested id
+var ErrClusterNotFound = errors.New("cluster: no cluster with the specified id")
+
+// ErrInvalidWeight is returned by ClusterPoints, when the weight of WeightedGeoPoint is not positive
+var ErrInvalidWeight = errors.New("cluster: weight of the point should be positive")
+
 
 // GeoCoordinates represent position in the Earth
 type GeoCoordinates struct {
@@ -22,21 +31,110 @@ type GeoPoint interface {
 	GetCoordinates() GeoCoordinates
 }
 
+// WeightedGeoPoint is optional interface for points with weight
+// Weighted points pull the cluster position to them, and their weight is summed in ClusterPoint.Weight
+// Weight should be positive, otherwise ClusterPoints returns ErrInvalidWeight,
+// the points, that are not implementing it, have weight 1
+type WeightedGeoPoint interface {
+	GeoPoint
+	GetWeight() float64
+}
+
+// CategorizedGeoPoint is optional interface for points of different kinds
+// Points are merged into the cluster only with the points of the same category,
+// so every category has its own clusters in the same index
+type CategorizedGeoPoint interface {
+	GeoPoint
+	GetCategory() string
+}
+
+// PrioritizedGeoPoint is optional interface for points with priority
+// It's used to choose the point to place the cluster on, when Cluster's Position is PositionPriority
+type PrioritizedGeoPoint interface {
+	GeoPoint
+	GetPriority() float64
+}
+
+// ClusterPosition defines where the cluster is placed
+// Clusters are grown around the weighted mean of points in all modes, so they consist of the same points,
+// and queries find them by the weighted mean, only returned X and Y of the cluster are different
+type ClusterPosition int
+
+const (
+	// PositionCentroid places the cluster to the weighted mean of its points
+	PositionCentroid ClusterPosition = iota
+	// PositionPriority places the cluster on its point with the highest priority,
+	// priority is GetPriority of PrioritizedGeoPoint, or weight of the point, if it's not implemented
+	PositionPriority
+	// PositionNearest places the cluster on its point, nearest to the weighted mean of its points
+	PositionNearest
+)
+
+// AttributedGeoPoint is optional interface for points with properties
+// Properties are written to encoded tiles and GeoJSON features of single points
+type AttributedGeoPoint interface {
+	GeoPoint
+	GetProperties() map[string]interface{}
+}
+
+// Aggregator accumulates custom properties of points, while they are merged into clusters
+// Map converts the initial point to accumulated value
+// Reduce merges two accumulated values and returns the new one, it should not modify its arguments
+type Aggregator interface {
+	Map(point GeoPoint) interface{}
+	Reduce(a, b interface{}) interface{}
+}
+
 //Struct that implements clustered points
 //could have only one point or set of points
+//Id of single point is the index of initial array of GeoPoint
+//Id of cluster encodes the index of the point the cluster was grown from and its zoom level,
+//so ids are stable for the same input
 type ClusterPoint struct {
 	X,Y float64
 	zoom int
 	Id int //Index for pint, Id for cluster
 	NumPoints int
-	//IncludedPoints []int TODO: Implement inclusion of objects
+	Weight float64 //total weight of points
+	Category string //category of points, empty if points are not implementing CategorizedGeoPoint
+	Aggregated interface{} //accumulated value of Cluster's Aggregator, nil if Aggregator is not set
+	counts *map[string]int //number of points per Cluster's CountBy key, it's shared by all copies of the point, see Counts
+	parentID int //Id of the cluster this point was merged into, 0 if none
+	leaf int //index of the initial point, the cluster is placed on, if Cluster's Position is not PositionCentroid
+	placed bool //the point is displayed at X, Y, but its position in the index is cx, cy, see Coordinates
+	cx, cy float64
 }
 
 
+// Coordinates returns the position of the point in the index,
+// it's the weighted mean of points, even if the cluster is placed on one of its points,
+// in Supercluster mode it's rounded to float32
 func (cp *ClusterPoint)	Coordinates() (float64, float64) {
+	if cp.placed {
+		return cp.cx, cp.cy
+	}
 	return cp.X, cp.Y
 }
 
+//copy of the point for query results, it's detached from the index,
+//so its Coordinates are X and Y, whatever they are translated to
+func (cp *ClusterPoint) detached() ClusterPoint {
+	result := *cp
+	result.placed = false
+	result.cx, result.cy = 0, 0
+	return result
+}
+
+// Counts returns the number of points per Cluster's CountBy key,
+// nil for single points and if CountBy is not set.
+// It returns a copy, so it could be modified without changing the index
+func (cp *ClusterPoint) Counts() map[string]int {
+	if cp.counts == nil {
+		return nil
+	}
+	return maps.Clone(*cp.counts)
+}
+
 
 
 // Cluster struct get a list or stream of geo objects
@@ -46,17 +144,31 @@ func (cp *ClusterPoint)	Coordinates() (float64, float64) {
 // Zoom range is limited by 0 to 21, and MinZoom could not be larger, then MaxZoom
 // PointSize - pixel size of marker, affects clustering radius
 // TileSize - size of tile in pixels, affects clustering radius
+// Aggregator - optional map/reduce of points properties, the result is stored in ClusterPoint.Aggregated
+// CountBy - optional function, that returns a key of the point, number of points per key is returned by ClusterPoint.Counts
+// Position - where the cluster is placed, PositionCentroid by default
+// Supercluster - compatibility mode with supercluster 7.x, see below
+//
+// In Supercluster mode clusters, their ids and tile coordinates are the same as supercluster returns for the same options:
+// WeightedGeoPoint, CategorizedGeoPoint and Position are ignored, so every point has weight 1,
+// points are indexed and clusters are positioned with float32 precision of Mercator coordinates,
+// while single points are returned with their initial coordinates,
+// pixel coordinates of tiles and viewports are rounded half up as JavaScript Math.round does,
+// and encoded clusters have point_count_abbreviated property.
+// Cluster ids are encoded the same way in both modes, but in the default mode points are indexed with float64 coordinates,
+// so points on the edge of the cluster radius could be clustered differently.
 type Cluster struct {
-	MinZoom   int
-	MaxZoom   int
-	PointSize int
-	TileSize  int
-	NodeSize  int
-	Indexes   []*kdbush.KDBush
-	Points    []GeoPoint
-
-	ClusterIdxSeed int
-	clusterIDLast int
+	MinZoom      int
+	MaxZoom      int
+	PointSize    int
+	TileSize     int
+	NodeSize     int
+	Indexes      []*kdbush.KDBush
+	Points       []GeoPoint
+	Aggregator   Aggregator
+	CountBy      func(point GeoPoint) string
+	Position     ClusterPosition
+	Supercluster bool
 }
 
 // Create new Cluster instance with default parameters:
@@ -80,23 +192,34 @@ func NewCluster() *Cluster {
 // All points should implement GeoPoint interface
 // they are not copied, so you could not worry about memory efficiency
 // And GetCoordinates called only once for each object, so you could calc it on the fly, if you need
+// Returns ErrInvalidWeight, if any point has not positive weight, the index is not changed in this case
 func (c *Cluster) ClusterPoints(points []GeoPoint) error {
 
 	//limit max Zoom
 	if c.MaxZoom > 21 { c.MaxZoom = 21 }
 
+	clusters, err := translateGeoPointsToClusterPoints(points, !c.Supercluster)
+	if err != nil {
+		return err
+	}
+
 	//adding extra layer for infinite zoom (initial) layers data storage
-	c.Indexes = make([]*kdbush.KDBush, c.MaxZoom-c.MinZoom+2)
+	c.Indexes = make([]*kdbush.KDBush, c.MaxZoom+2)
 	c.Points = points
 
-	//get digits number, start from next exponent
-	//if we have 78, all cluster will start from 100...
-	//if we have 986 points, all clusters ids will start from 1000
-	c.ClusterIdxSeed = int(math.Pow(10,float64(digitsCount(len(points)))))
-	c.clusterIDLast = c.ClusterIdxSeed
-
+	if c.Aggregator != nil {
+		for i := range clusters {
+			clusters[i].Aggregated = c.Aggregator.Map(points[i])
+		}
+	}
 
-	clusters := translateGeoPointsToClusterPoints(points)
+	if c.Supercluster {
+		//supercluster indexes points with float32 coordinates, but returns them with the initial ones
+		for _, p := range clusters {
+			p.placed = true
+			p.cx, p.cy = fround(p.X), fround(p.Y)
+		}
+	}
 
 	for z := c.MaxZoom; z >= c.MinZoom; z-- {
 
@@ -116,25 +239,51 @@ func (c *Cluster) ClusterPoints(points []GeoPoint) error {
 // The northWest and southEast points are boundary points of square, that should be returned.
 // nothWest is left topmost point.
 // southEast is right bottom point.
+// If west longitude is larger than east one, the square is crossing 180 meridian.
+// Longitudes outside of -180..180 range (world copies) are wrapped.
+// Earlier versions required the swapped corners, for example northWest {71.36, -83.79} and southEast {-71.01, 83.75}
+// for the box from -71.01 to 71.36 longitude, such calls now return the box crossing 180 meridian instead.
 // return the object for clustered points,
 // X coordinate of returned object is Longitude and
 // Y coordinate of returned object is Latitude
 func (c *Cluster)GetClusters(northWest, southEast GeoPoint, zoom int) []ClusterPoint {
-	index := c.Indexes[c.limitZoom(zoom)]
-	nwX, nwY := MercatorProjection(northWest.GetCoordinates())
-	seX, seY := MercatorProjection(southEast.GetCoordinates())
-	ids := index.Range(seX, seY,nwX,nwY)
-	var result []ClusterPoint = make ([]ClusterPoint, len(ids))
-	for i := range ids {
-		p := index.Points[ids[i]].(*ClusterPoint)
-		cp := *p
-		coordinates :=  ReverseMercatorProjection(cp.X, cp.Y)
-		cp.X = coordinates.Lon
-		cp.Y = coordinates.Lat
-		result[i] = cp
+	index, ids := c.clustersInBox(northWest, southEast, zoom)
+	return appendLatLonPoints(make([]ClusterPoint, 0, len(ids)), ids, index.Points)
+}
+
+// AppendClusters appends clusters for zoom level inside the box to dst and returns the extended slice,
+// the same way as GetClusters, so the buffer could be reused between queries.
+func (c *Cluster) AppendClusters(dst []ClusterPoint, northWest, southEast GeoPoint, zoom int) []ClusterPoint {
+	index, ids := c.clustersInBox(northWest, southEast, zoom)
+	return appendLatLonPoints(dst, ids, index.Points)
+}
+
+// GetClustersSeq returns the iterator over clusters for zoom level inside the box, the same as GetClusters,
+// points are converted to LatLon one by one, without allocation of the result slice.
+func (c *Cluster) GetClustersSeq(northWest, southEast GeoPoint, zoom int) iter.Seq[ClusterPoint] {
+	return func(yield func(ClusterPoint) bool) {
+		index, ids := c.clustersInBox(northWest, southEast, zoom)
+		for _, id := range ids {
+			if !yield(latLonPoint(index.Points[id])) {
+				return
+			}
+		}
 	}
+}
 
-	return result
+//find index for zoom level and ids of its points inside the box
+func (c *Cluster) clustersInBox(northWest, southEast GeoPoint, zoom int) (*kdbush.KDBush, []int) {
+	index := c.Indexes[c.limitZoom(zoom)]
+	nw := northWest.GetCoordinates()
+	se := southEast.GetCoordinates()
+	west, east := normalizeLon(nw.Lon), normalizeLon(se.Lon)
+	if se.Lon == 180 {
+		east = 180
+	}
+	if se.Lon-nw.Lon >= 360 {
+		west, east = -180, 180
+	}
+	return index, rangeBox(index, west, math.Min(nw.Lat, se.Lat), east, math.Max(nw.Lat, se.Lat))
 }
 
 // AllClusters returns all cluster points, array of ClusterPoint,  for zoom on the map.
@@ -142,67 +291,84 @@ func (c *Cluster)GetClusters(northWest, southEast GeoPoint, zoom int) []ClusterP
 // Y coordinate of returned object is Latitude.
 func (c *Cluster)AllClusters(zoom int) []ClusterPoint {
 	index := c.Indexes[c.limitZoom(zoom)]
-	points := index.Points
-	var result []ClusterPoint = make ([]ClusterPoint, len(points))
-	for i := range points {
-		p := index.Points[i].(*ClusterPoint)
-		cp := *p
-		coordinates :=  ReverseMercatorProjection(cp.X, cp.Y)
-		cp.X = coordinates.Lon
-		cp.Y = coordinates.Lat
-		result[i] = cp
+	return c.AppendAllClusters(make([]ClusterPoint, 0, len(index.Points)), zoom)
+}
+
+// AppendAllClusters appends all cluster points for zoom to dst and returns the extended slice,
+// the same way as AllClusters, so the buffer could be reused between queries.
+func (c *Cluster) AppendAllClusters(dst []ClusterPoint, zoom int) []ClusterPoint {
+	index := c.Indexes[c.limitZoom(zoom)]
+	for _, p := range index.Points {
+		dst = append(dst, latLonPoint(p))
 	}
+	return dst
+}
 
-	return result
+// AllClustersSeq returns the iterator over all cluster points for zoom, the same as AllClusters,
+// points are converted to LatLon one by one, without allocation of the result slice.
+func (c *Cluster) AllClustersSeq(zoom int) iter.Seq[ClusterPoint] {
+	return func(yield func(ClusterPoint) bool) {
+		index := c.Indexes[c.limitZoom(zoom)]
+		for _, p := range index.Points {
+			if !yield(latLonPoint(p)) {
+				return
+			}
+		}
+	}
 }
 
 
 //return points for  Tile with coordinates x and y and for zoom z
 // return objects with pixel coordinates
 func (c *Cluster)GetTile(x,y,z int) []ClusterPoint {
-	return c.getTile(x,y,z,false)
+	return c.getTile(x,y,z,false,c.TileSize)
 }
 
 //return points for  Tile with coordinates x and y and for zoom z
 // return objects with LatLon coordinates
 func (c *Cluster)GetTileWithLatLon(x,y,z int) []ClusterPoint {
-	return c.getTile(x,y,z,true)
+	return c.getTile(x,y,z,true,c.TileSize)
 }
 
-func (c *Cluster)getTile(x,y,z int, latlon bool) []ClusterPoint {
+//extent is the size of the tile in returned pixel coordinates, if latlon is false
+func (c *Cluster)getTile(x,y,z int, latlon bool, extent int) []ClusterPoint {
+	return c.getTileFiltered(x,y,z,latlon,extent,nil)
+}
+
+//tile with the points, that match the filter, see filterPoints
+func (c *Cluster)getTileFiltered(x,y,z int, latlon bool, extent int, filter func(GeoPoint) bool) []ClusterPoint {
 	index := c.Indexes[c.limitZoom(z)]
 	z2 := 1 << uint(z)
 	z2f := float64(z2)
-	extent := c.TileSize
 	r := c.PointSize
-	p := float64(r) / float64(extent)
+	p := float64(r) / float64(c.TileSize)
 	top := (float64(y) - p)/z2f
 	bottom := (float64(y)+1+p) / z2f
 
-	resultIds := index.Range(
+	resultIds, points := c.filterPoints(index.Range(
 		(float64(x)-p)/ z2f ,
 		float64(top),
 		(float64(x)+1+p)/z2f,
 		bottom,
-	)
+	), index.Points, filter)
 	var result []ClusterPoint
 	if latlon == true {
-		result = c.pointIDToLatLonPoint(resultIds,index.Points)
+		result = c.pointIDToLatLonPoint(resultIds,points)
 	} else {
-		result = c.pointIDToMerkatorPoint(resultIds,index.Points,float64(x),float64(y),z2f)
+		result = c.pointIDToMerkatorPoint(resultIds,points,float64(x),float64(y),z2f,extent)
 	}
 
 	if (x == 0) {
-		minX1 := float64(1-p)/z2f
+		minX1 := 1 - p/z2f
 		minY1 := float64(top)
 		maxX1 := 1.0
 		maxY1 := float64(bottom)
-		resultIds = index.Range(minX1, minY1, maxX1, maxY1)
+		resultIds, points = c.filterPoints(index.Range(minX1, minY1, maxX1, maxY1), index.Points, filter)
 		var sr1 []ClusterPoint
 		if latlon == true {
-			sr1 = c.pointIDToLatLonPoint(resultIds, index.Points)
+			sr1 = c.pointIDToLatLonPoint(resultIds, points)
 		} else {
-			sr1 = c.pointIDToMerkatorPoint(resultIds, index.Points, z2f, float64(y), z2f)
+			sr1 = c.pointIDToMerkatorPoint(resultIds, points, z2f, float64(y), z2f, extent)
 		}
 		result =  append(result, sr1...)
 
@@ -213,12 +379,12 @@ func (c *Cluster)getTile(x,y,z int, latlon bool) []ClusterPoint {
 		minY2 := float64(top)
 		maxX2 := float64(p)/z2f
 		maxY2 := float64(bottom)
-		resultIds = index.Range(minX2, minY2, maxX2, maxY2)
+		resultIds, points = c.filterPoints(index.Range(minX2, minY2, maxX2, maxY2), index.Points, filter)
 		var sr2 []ClusterPoint
 		if latlon == true {
-			sr2 = c.pointIDToLatLonPoint(resultIds, index.Points)
+			sr2 = c.pointIDToLatLonPoint(resultIds, points)
 		} else {
-			sr2 = c.pointIDToMerkatorPoint(resultIds,index.Points,-1,float64(y),z2f)
+			sr2 = c.pointIDToMerkatorPoint(resultIds,points,-1,float64(y),z2f,extent)
 		}
 		result =  append(result, sr2...)
 	}
@@ -228,32 +394,187 @@ func (c *Cluster)getTile(x,y,z int, latlon bool) []ClusterPoint {
 }
 
 //calc Point mercator projection regarding tile
-func(c *Cluster) pointIDToMerkatorPoint(ids []int, points []kdbush.Point, x, y, z2 float64) []ClusterPoint {
+func(c *Cluster) pointIDToMerkatorPoint(ids []int, points []kdbush.Point, x, y, z2 float64, extent int) []ClusterPoint {
 	var result []ClusterPoint
 	for i := range ids {
 		p := points[ids[i]].(*ClusterPoint)
-		cp := *p
-		//translate our coordinate system to mercator
-		cp.X = float64(round(float64(c.TileSize) *(p.X*z2-x)))
-		cp.Y = float64(round(float64(c.TileSize) *(p.Y*z2-y)))
-		cp.zoom = 0
-		result = append(result,cp)
+		result = append(result, c.tilePoint(p, p.X*z2-x, p.Y*z2-y, extent))
 	}
 	return result
 }
-func(c *Cluster) pointIDToLatLonPoint(ids []int, points []kdbush.Point) []ClusterPoint {
-	var result []ClusterPoint = make ([]ClusterPoint, len(ids))
-	for i := range ids {
-		p := points[ids[i]].(*ClusterPoint)
-		cp := *p
-		coordinates :=  ReverseMercatorProjection(cp.X, cp.Y)
+
+//copy of the point with pixel coordinates of the tile with extent size
+//x and y are the point coordinates relative to the tile, in tile units
+func (c *Cluster) tilePoint(p *ClusterPoint, x, y float64, extent int) ClusterPoint {
+	cp := p.detached()
+	//translate our coordinate system to mercator
+	cp.X = c.roundPixel(float64(extent) * x)
+	cp.Y = c.roundPixel(float64(extent) * y)
+	cp.zoom = 0
+	return cp
+}
+
+// GetChildren returns the children of the cluster on the next zoom level,
+// it could be clusters or single points.
+// X coordinate of returned object is Longitude and
+// Y coordinate of returned object is Latitude
+// Returns ErrClusterNotFound if clusterID is not an id of cluster
+func (c *Cluster) GetChildren(clusterID int) ([]ClusterPoint, error) {
+	children := c.getChildren(clusterID)
+	if len(children) == 0 {
+		return nil, ErrClusterNotFound
+	}
+	result := make([]ClusterPoint, len(children))
+	for i, p := range children {
+		cp := p.detached()
+		coordinates := ReverseMercatorProjection(cp.X, cp.Y)
 		cp.X = coordinates.Lon
 		cp.Y = coordinates.Lat
 		result[i] = cp
 	}
+	return result, nil
+}
+
+// GetClusterExpansionZoom returns the smallest zoom level, at which the cluster
+// splits into more than one point or cluster.
+// If clusterID is not an id of cluster, MaxZoom+1 is returned,
+// as all points are not clustered on that zoom level
+func (c *Cluster) GetClusterExpansionZoom(clusterID int) int {
+	originZoom, _, ok := c.clusterOrigin(clusterID)
+	if !ok {
+		return c.MaxZoom + 1
+	}
+	expansionZoom := originZoom - 1
+	for expansionZoom <= c.MaxZoom {
+		children := c.getChildren(clusterID)
+		expansionZoom++
+		if len(children) != 1 {
+			break
+		}
+		clusterID = children[0].Id
+	}
+	return expansionZoom
+}
+
+// GetLeaves returns original points of the cluster, walking down to the initial points.
+// limit is the max number of points to return, if limit <= 0 all points are returned
+// offset is the number of points to skip, to paginate over the large clusters
+// Returns ErrClusterNotFound if clusterID is not an id of cluster
+func (c *Cluster) GetLeaves(clusterID, limit, offset int) ([]GeoPoint, error) {
+	if _, _, ok := c.clusterOrigin(clusterID); !ok {
+		return nil, ErrClusterNotFound
+	}
+	var ids []int
+	c.appendLeaves(&ids, clusterID, limit, offset, 0)
+	result := make([]GeoPoint, len(ids))
+	for i, id := range ids {
+		result[i] = c.Points[id]
+	}
+	return result, nil
+}
+
+//append indexes of cluster's original points to result, returns number of skipped points
+func (c *Cluster) appendLeaves(result *[]int, clusterID, limit, offset, skipped int) int {
+	for _, child := range c.getChildren(clusterID) {
+		if child.NumPoints > 1 {
+			if skipped+child.NumPoints <= offset {
+				//skip the whole cluster
+				skipped += child.NumPoints
+			} else {
+				skipped = c.appendLeaves(result, child.Id, limit, offset, skipped)
+			}
+		} else if skipped < offset {
+			skipped++
+		} else {
+			*result = append(*result, child.Id)
+		}
+		if limit > 0 && len(*result) == limit {
+			break
+		}
+	}
+	return skipped
+}
+
+// GetAncestors returns the ids of the clusters, that contain the original point with pointIndex,
+// for every zoom level from MaxZoom down to MinZoom.
+// If the point is not clustered on the zoom level, the pointIndex is used.
+// Returns nil if pointIndex is out of initial points range
+func (c *Cluster) GetAncestors(pointIndex int) []int {
+	if c.Indexes == nil || pointIndex < 0 || pointIndex >= len(c.Points) {
+		return nil
+	}
+	p := c.Indexes[c.MaxZoom+1].Points[pointIndex].(*ClusterPoint)
+	result := make([]int, 0, c.MaxZoom-c.MinZoom+1)
+	for z := c.MaxZoom; z >= c.MinZoom; z-- {
+		//the point is merged to parent cluster on this zoom level
+		if parentZoom, _, ok := c.clusterOrigin(p.parentID); ok && parentZoom == z+1 {
+			p = c.getCluster(p.parentID)
+		}
+		result = append(result, p.Id)
+	}
 	return result
 }
 
+//find cluster by id in the index, it has been created for
+func (c *Cluster) getCluster(clusterID int) *ClusterPoint {
+	originZoom, originIdx, ok := c.clusterOrigin(clusterID)
+	if !ok {
+		return nil
+	}
+	origin := c.Indexes[originZoom].Points[originIdx]
+	//cluster is inside the cluster radius around origin point
+	tree := c.Indexes[originZoom-1]
+	r := float64(c.PointSize) / float64(c.TileSize*(1<<uint(originZoom-1)))
+	for _, id := range tree.Within(origin, r) {
+		p := tree.Points[id].(*ClusterPoint)
+		if p.Id == clusterID {
+			return p
+		}
+	}
+	return nil
+}
+
+//find all points merged into cluster, with projection coordinates
+func (c *Cluster) getChildren(clusterID int) []*ClusterPoint {
+	originZoom, originIdx, ok := c.clusterOrigin(clusterID)
+	if !ok {
+		return nil
+	}
+	tree := c.Indexes[originZoom]
+	//all children are inside the cluster radius around origin point
+	r := float64(c.PointSize) / float64(c.TileSize*(1<<uint(originZoom-1)))
+	ids := tree.Within(tree.Points[originIdx], r)
+	var result []*ClusterPoint
+	for _, id := range ids {
+		p := tree.Points[id].(*ClusterPoint)
+		if p.parentID == clusterID {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func(c *Cluster) pointIDToLatLonPoint(ids []int, points []kdbush.Point) []ClusterPoint {
+	return appendLatLonPoints(make([]ClusterPoint, 0, len(ids)), ids, points)
+}
+
+//append points with ids to dst, translated to LatLon coordinates
+func appendLatLonPoints(dst []ClusterPoint, ids []int, points []kdbush.Point) []ClusterPoint {
+	for _, id := range ids {
+		dst = append(dst, latLonPoint(points[id]))
+	}
+	return dst
+}
+
+//copy of the point with LatLon coordinates
+func latLonPoint(p kdbush.Point) ClusterPoint {
+	cp := p.(*ClusterPoint).detached()
+	coordinates := ReverseMercatorProjection(cp.X, cp.Y)
+	cp.X = coordinates.Lon
+	cp.Y = coordinates.Lat
+	return cp
+}
+
 
 
 //clusterize points for zoom level
@@ -273,23 +594,44 @@ func (c *Cluster)clusterize(points []*ClusterPoint, zoom int) []*ClusterPoint {
 
 		//find all neighbours
 		tree := c.Indexes[zoom+1]
-		neighbourIds := tree.Within(&kdbush.SimplePoint{X:p.X,Y:p.Y},r)
+		//clusters are grown around weighted means, wherever they are placed
+		px, py := p.Coordinates()
+		neighbourIds := tree.Within(&kdbush.SimplePoint{X:px,Y:py},r)
 
 		nPoints := p.NumPoints
-		wx := p.X * float64(nPoints)
-		wy := p.Y * float64(nPoints)
+		weight := p.Weight
+		wx := px * weight
+		wy := py * weight
+		aggregated := p.Aggregated
+		var counts map[string]int
+		if c.CountBy != nil {
+			counts = make(map[string]int)
+			c.addCounts(counts, p)
+		}
 
 		var foundNeighbours []*ClusterPoint
+		//encode origin point index and zoom level of its index into cluster id
+		clusterID := (pi << 5) + (zoom + 1) + len(c.Points)
 
 		for j := range neighbourIds {
 			b := points[neighbourIds[j]]
 
 			//Filter out neighbours, that are already processed (and processed point "p" as well)
-			if zoom < b.zoom {
-				wx += b.X * float64(b.NumPoints)
-				wy += b.Y * float64(b.NumPoints)
+			//and neighbours of other categories
+			if zoom < b.zoom && b.Category == p.Category {
+				bx, by := b.Coordinates()
+				wx += bx * b.Weight
+				wy += by * b.Weight
 				nPoints += b.NumPoints
+				weight += b.Weight
+				if c.Aggregator != nil {
+					aggregated = c.Aggregator.Reduce(aggregated, b.Aggregated)
+				}
+				if counts != nil {
+					c.addCounts(counts, b)
+				}
 				b.zoom = zoom //set the zoom to skip in other iterations
+				b.parentID = clusterID
 				foundNeighbours = append(foundNeighbours, b)
 			}
 		}
@@ -298,18 +640,110 @@ func (c *Cluster)clusterize(points []*ClusterPoint, zoom int) []*ClusterPoint {
 		//create new cluster
 		if len(foundNeighbours)>0 {
 			newCluster = &ClusterPoint{}
-			newCluster.X = wx / float64(nPoints)
-			newCluster.Y = wy / float64(nPoints)
+			newCluster.X = wx / weight
+			newCluster.Y = wy / weight
+			if c.Supercluster {
+				newCluster.X, newCluster.Y = fround(newCluster.X), fround(newCluster.Y)
+			}
+			if c.Position != PositionCentroid && !c.Supercluster {
+				//place the cluster on one of the initial points, the weighted mean is kept for the index
+				newCluster.leaf = c.pickLeaf(append(foundNeighbours, p), newCluster.X, newCluster.Y)
+				leaf := c.Indexes[c.MaxZoom+1].Points[newCluster.leaf].(*ClusterPoint)
+				newCluster.placed = true
+				newCluster.cx, newCluster.cy = newCluster.X, newCluster.Y
+				newCluster.X, newCluster.Y = leaf.X, leaf.Y
+			}
 			newCluster.NumPoints = nPoints
+			newCluster.Weight = weight
+			newCluster.Category = p.Category
+			newCluster.Aggregated = aggregated
+			if counts != nil {
+				newCluster.counts = &counts
+			}
 			newCluster.zoom = InfinityZoomLevel
-			newCluster.Id = c.clusterIDLast
-			c.clusterIDLast += 1
+			newCluster.Id = clusterID
+			p.parentID = clusterID
 		}
 		result = append(result, newCluster)
 	}
 	return result
 }
 
+//choose the initial point to place the cluster on, from the initial points of clusters
+//x and y is weighted mean of cluster points
+func (c *Cluster) pickLeaf(clusters []*ClusterPoint, x, y float64) int {
+	leaves := c.Indexes[c.MaxZoom+1].Points
+	var candidates []int
+	for _, p := range clusters {
+		switch {
+		case p.Id < len(c.Points):
+			candidates = append(candidates, p.Id)
+		case c.Position == PositionPriority:
+			//the child cluster is placed on its point with the highest priority
+			candidates = append(candidates, p.leaf)
+		default:
+			//any point of the child cluster could be the nearest one
+			c.appendLeaves(&candidates, p.Id, 0, 0, 0)
+		}
+	}
+
+	result := -1
+	best := 0.0
+	for _, leaf := range candidates {
+		var value float64
+		switch c.Position {
+		case PositionPriority:
+			if pp, ok := c.Points[leaf].(PrioritizedGeoPoint); ok {
+				value = pp.GetPriority()
+			} else {
+				value = leaves[leaf].(*ClusterPoint).Weight
+			}
+		default:
+			//the nearest point has the highest value
+			lp := leaves[leaf].(*ClusterPoint)
+			value = -((lp.X-x)*(lp.X-x) + (lp.Y-y)*(lp.Y-y))
+		}
+		if result < 0 || value > best {
+			result = leaf
+			best = value
+		}
+	}
+	return result
+}
+
+//add the number of points per CountBy key of point p to counts
+func (c *Cluster) addCounts(counts map[string]int, p *ClusterPoint) {
+	if p.counts == nil {
+		counts[c.CountBy(c.Points[p.Id])]++
+		return
+	}
+	for key, n := range *p.counts {
+		counts[key] += n
+	}
+}
+
+// clusterOrigin decodes cluster id to the zoom level of the index with cluster's children
+// and the position of the point, cluster was grown from, in that index
+func (c *Cluster) clusterOrigin(clusterID int) (int, int, bool) {
+	if clusterID <= len(c.Points) || c.Indexes == nil {
+		return 0, 0, false
+	}
+	originIdx := (clusterID - len(c.Points)) >> 5
+	originZoom := (clusterID - len(c.Points)) % 32
+	if originZoom <= c.MinZoom || originZoom > c.MaxZoom+1 {
+		return 0, 0, false
+	}
+	tree := c.Indexes[originZoom]
+	if originIdx >= len(tree.Points) {
+		return 0, 0, false
+	}
+	//origin point is marked as a child of the cluster
+	if tree.Points[originIdx].(*ClusterPoint).parentID != clusterID {
+		return 0, 0, false
+	}
+	return originZoom, originIdx, true
+}
+
 func (c *Cluster)limitZoom(zoom int) int {
 	if zoom > c.MaxZoom+1 { zoom = c.MaxZoom+1 }
 	if zoom < c.MinZoom   { zoom = c.MinZoom }
@@ -323,7 +757,8 @@ func (c *Cluster)limitZoom(zoom int) int {
 /////////////////////////////////
 
 //translate geopoints to ClusterPoints witrh projection coordinates
-func translateGeoPointsToClusterPoints(points []GeoPoint) []*ClusterPoint {
+//weights and categories of points are read only if extended is true
+func translateGeoPointsToClusterPoints(points []GeoPoint, extended bool) ([]*ClusterPoint, error) {
 	var result = make([]*ClusterPoint, len(points))
 	for i, p := range points {
 		cp := ClusterPoint{}
@@ -331,9 +766,20 @@ func translateGeoPointsToClusterPoints(points []GeoPoint) []*ClusterPoint {
 		cp.X, cp.Y = MercatorProjection(p.GetCoordinates())
 		result[i] = &cp
 		cp.NumPoints = 1
+		cp.Weight = 1
+		if wp, ok := p.(WeightedGeoPoint); ok && extended {
+			cp.Weight = wp.GetWeight()
+			//zero sum of weights makes the cluster position undefined
+			if !(cp.Weight > 0) || math.IsInf(cp.Weight, 1) {
+				return nil, ErrInvalidWeight
+			}
+		}
+		if cgp, ok := p.(CategorizedGeoPoint); ok && extended {
+			cp.Category = cgp.GetCategory()
+		}
 		cp.Id = i
 	}
-	return result
+	return result, nil
 }
 
 
@@ -355,15 +801,20 @@ func ReverseMercatorProjection(x, y float64) GeoCoordinates {
 }
 
 
-//count number of digits, for example 123356 will return 6
-func digitsCount(a int) int {
-	return  int(math.Floor(math.Log10( math.Abs (float64(a))))) + 1
-	//result := 0
-	//for a != 0 {
-	//	a /= 10
-	//	result += 1
-	//}
-	//return result
+//ids of the points inside the box, limited by longitudes and latitudes
+//if west is larger than east, the box is crossing 180 meridian and it's split into two
+func rangeBox(index *kdbush.KDBush, west, south, east, north float64) []int {
+	minX, minY := MercatorProjection(GeoCoordinates{Lon: west, Lat: north})
+	maxX, maxY := MercatorProjection(GeoCoordinates{Lon: east, Lat: south})
+	if west > east {
+		return append(index.Range(minX, minY, 1, maxY), index.Range(0, minY, maxX, maxY)...)
+	}
+	return index.Range(minX, minY, maxX, maxY)
+}
+
+//wrap longitude to [-180..180) range
+func normalizeLon(lon float64) float64 {
+	return math.Mod(math.Mod(lon+180, 360)+360, 360) - 180
 }
 
 func clustersToPoints(points []*ClusterPoint) []kdbush.Point {
@@ -377,4 +828,22 @@ func round(val float64) int {
 		return int(val-0.5)
 	}
 	return int(val+0.5)
-}
\ No newline at end of file
+}
+
+//round pixel coordinate, the same way as supercluster does in Supercluster mode
+func (c *Cluster) roundPixel(val float64) float64 {
+	if c.Supercluster {
+		return float64(jsRound(val))
+	}
+	return float64(round(val))
+}
+
+//round to float32 precision, the same way as JavaScript Math.fround
+func fround(val float64) float64 {
+	return float64(float32(val))
+}
+
+//round half up, the same way as JavaScript Math.round
+func jsRound(val float64) int {
+	return int(math.Floor(val + 0.5))
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
+	"slices"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -101,8 +104,8 @@ func TestCluster_GetClusters(t *testing.T) {
 	c.NodeSize = 64
 	c.ClusterPoints(geoPoints)
 
-	northWest := simplePoint{71.36718750000001, -83.79204408779539}
-	southEast := simplePoint{-71.01562500000001, 83.7539108491127}
+	northWest := simplePoint{-71.01562500000001, 83.7539108491127}
+	southEast := simplePoint{71.36718750000001, -83.79204408779539}
 
 	var result []ClusterPoint = c.GetClusters(northWest, southEast, 2)
 	assert.NotEmpty(t, result)
@@ -124,6 +127,73 @@ func TestCluster_GetClusters(t *testing.T) {
 	//fmt.Printf("getting points %v \n",string(resultJSON))
 }
 
+func TestCluster_GetClustersSwappedCorners(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	//corners as they had to be passed before 180 meridian support,
+	//now it's the box crossing 180 meridian, outside of -71.01..71.36 longitudes
+	northWest := simplePoint{71.36718750000001, -83.79204408779539}
+	southEast := simplePoint{-71.01562500000001, 83.7539108491127}
+	var expected []int
+	for _, cp := range c.AllClusters(2) {
+		if (cp.X >= northWest.Lon || cp.X <= southEast.Lon) && cp.Y >= northWest.Lat && cp.Y <= southEast.Lat {
+			expected = append(expected, cp.Id)
+		}
+	}
+	var ids []int
+	for _, cp := range c.GetClusters(northWest, southEast, 2) {
+		ids = append(ids, cp.Id)
+	}
+	assert.NotEmpty(t, ids)
+	assert.ElementsMatch(t, expected, ids)
+}
+
+
+func TestCluster_GetClustersAntimeridian(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	inside := func(cp ClusterPoint) bool {
+		return (cp.X >= 150 || cp.X <= -150) && cp.Y >= -60 && cp.Y <= 60
+	}
+	zoom := 3
+	var expected []int
+	for _, cp := range c.AllClusters(zoom) {
+		if inside(cp) {
+			expected = append(expected, cp.Id)
+		}
+	}
+	assert.NotEmpty(t, expected)
+
+	boxes := [][2]simplePoint{
+		{{150, 60}, {-150, -60}},
+		//world copies
+		{{150, 60}, {210, -60}},
+		{{-210, 60}, {-150, -60}},
+		{{510, 60}, {570, -60}},
+	}
+	for _, box := range boxes {
+		var ids []int
+		for _, cp := range c.GetClusters(box[0], box[1], zoom) {
+			ids = append(ids, cp.Id)
+		}
+		assert.ElementsMatch(t, expected, ids, "box %v", box)
+	}
+
+	whole := c.GetClusters(simplePoint{-200, 85}, simplePoint{200, -85}, zoom)
+	assert.Equal(t, len(c.AllClusters(zoom)), len(whole))
+}
 
 func TestCluster_AllClusters(t *testing.T) {
 	points := importData("./testdata/places.json")
@@ -154,6 +224,501 @@ func TestCluster_AllClusters(t *testing.T) {
 	assert.Equal(t, 100, len(result))
 
 }
+
+func TestCluster_GetChildren(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	for _, cp := range c.AllClusters(0) {
+		if cp.NumPoints == 1 {
+			continue
+		}
+		children, err := c.GetChildren(cp.Id)
+		assert.NoError(t, err)
+		assert.True(t, len(children) > 1)
+		numPoints := 0
+		for _, child := range children {
+			numPoints += child.NumPoints
+		}
+		assert.Equal(t, cp.NumPoints, numPoints)
+	}
+
+	_, err := c.GetChildren(0)
+	assert.Equal(t, ErrClusterNotFound, err)
+}
+
+func TestCluster_GetLeaves(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	clusters := c.AllClusters(0)
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].NumPoints > clusters[j].NumPoints })
+	cp := clusters[0]
+
+	leaves, err := c.GetLeaves(cp.Id, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, cp.NumPoints, len(leaves))
+
+	page1, _ := c.GetLeaves(cp.Id, 5, 0)
+	page2, _ := c.GetLeaves(cp.Id, 5, 5)
+	assert.Equal(t, leaves[:5], page1)
+	assert.Equal(t, leaves[5:10], page2)
+
+	tail, _ := c.GetLeaves(cp.Id, 5, cp.NumPoints-3)
+	assert.Equal(t, leaves[cp.NumPoints-3:], tail)
+
+	_, err = c.GetLeaves(0, 10, 0)
+	assert.Equal(t, ErrClusterNotFound, err)
+}
+
+func TestCluster_GetClusterExpansionZoom(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	hasID := func(clusters []ClusterPoint, id int) bool {
+		for _, cp := range clusters {
+			if cp.Id == id {
+				return true
+			}
+		}
+		return false
+	}
+	for _, cp := range c.AllClusters(0) {
+		if cp.NumPoints == 1 {
+			continue
+		}
+		zoom := c.GetClusterExpansionZoom(cp.Id)
+		assert.True(t, hasID(c.AllClusters(zoom-1), cp.Id))
+		assert.False(t, hasID(c.AllClusters(zoom), cp.Id))
+	}
+
+	assert.Equal(t, c.MaxZoom+1, c.GetClusterExpansionZoom(0))
+}
+
+func TestCluster_GetAncestors(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	for _, idx := range []int{0, 22, 62, 161} {
+		ancestors := c.GetAncestors(idx)
+		assert.Equal(t, c.MaxZoom-c.MinZoom+1, len(ancestors))
+		for i, id := range ancestors {
+			zoom := c.MaxZoom - i
+			found := false
+			for _, cp := range c.AllClusters(zoom) {
+				if cp.Id != id {
+					continue
+				}
+				found = true
+				if cp.NumPoints == 1 {
+					assert.Equal(t, idx, id)
+				} else {
+					leaves, _ := c.GetLeaves(id, 0, 0)
+					assert.Contains(t, leaves, geoPoints[idx])
+				}
+			}
+			assert.True(t, found, "no cluster %v on zoom %v", id, zoom)
+		}
+	}
+
+	assert.Nil(t, c.GetAncestors(len(geoPoints)))
+}
+
+type countAggregator struct{}
+
+func (countAggregator) Map(point GeoPoint) interface{} { return 1 }
+func (countAggregator) Reduce(a, b interface{}) interface{} { return a.(int) + b.(int) }
+
+func TestCluster_Aggregator(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	c.Aggregator = countAggregator{}
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	for z := c.MinZoom; z <= c.MaxZoom+1; z++ {
+		for _, cp := range c.AllClusters(z) {
+			assert.Equal(t, cp.NumPoints, cp.Aggregated)
+		}
+	}
+}
+
+type weightedPoint struct {
+	simplePoint
+	weight float64
+}
+
+func (wp weightedPoint) GetWeight() float64 { return wp.weight }
+
+func TestCluster_WeightedPoints(t *testing.T) {
+	geoPoints := []GeoPoint{
+		weightedPoint{simplePoint{10, 10}, 3},
+		weightedPoint{simplePoint{10.1, 10}, 1},
+		simplePoint{-100, -50},
+	}
+	c := NewCluster()
+	c.ClusterPoints(geoPoints)
+
+	result := c.AllClusters(0)
+	assert.Equal(t, 2, len(result))
+	for _, cp := range result {
+		if cp.NumPoints == 1 {
+			assert.Equal(t, 1.0, cp.Weight)
+			continue
+		}
+		assert.Equal(t, 2, cp.NumPoints)
+		assert.Equal(t, 4.0, cp.Weight)
+		assert.True(t, floatEquals(cp.X, 10.025))
+	}
+}
+
+func TestCluster_InvalidWeight(t *testing.T) {
+	for _, weight := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		c := NewCluster()
+		err := c.ClusterPoints([]GeoPoint{
+			weightedPoint{simplePoint{10, 10}, weight},
+			weightedPoint{simplePoint{10.1, 10}, weight},
+		})
+		assert.Equal(t, ErrInvalidWeight, err, "weight %v", weight)
+		assert.Nil(t, c.Indexes)
+	}
+}
+
+type categorizedPoint struct {
+	simplePoint
+	category string
+}
+
+func (cp categorizedPoint) GetCategory() string { return cp.category }
+
+func TestCluster_CategorizedPoints(t *testing.T) {
+	geoPoints := []GeoPoint{
+		categorizedPoint{simplePoint{10, 10}, "hotel"},
+		categorizedPoint{simplePoint{10.1, 10}, "restaurant"},
+		categorizedPoint{simplePoint{10.2, 10}, "hotel"},
+		categorizedPoint{simplePoint{10.3, 10}, "restaurant"},
+		categorizedPoint{simplePoint{10.4, 10}, "restaurant"},
+	}
+	c := NewCluster()
+	c.ClusterPoints(geoPoints)
+
+	result := c.AllClusters(0)
+	assert.Equal(t, 2, len(result))
+	numPoints := map[string]int{}
+	for _, cp := range result {
+		numPoints[cp.Category] = cp.NumPoints
+		leaves, err := c.GetLeaves(cp.Id, 0, 0)
+		assert.NoError(t, err)
+		for _, leaf := range leaves {
+			assert.Equal(t, cp.Category, leaf.(categorizedPoint).category)
+		}
+	}
+	assert.Equal(t, map[string]int{"hotel": 2, "restaurant": 3}, numPoints)
+}
+
+func TestCluster_CountBy(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	c.CountBy = func(p GeoPoint) string { return p.(*TestPoint).Properties.FeatureClass }
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	for _, cp := range c.AllClusters(0) {
+		if cp.NumPoints == 1 {
+			assert.Nil(t, cp.Counts())
+			continue
+		}
+		leaves, _ := c.GetLeaves(cp.Id, 0, 0)
+		counts := map[string]int{}
+		for _, leaf := range leaves {
+			counts[leaf.(*TestPoint).Properties.FeatureClass]++
+		}
+		assert.Equal(t, counts, cp.Counts())
+	}
+
+	//results are comparable and modifying counts doesn't change the index
+	clusters := c.AllClusters(0)
+	for _, cp := range clusters {
+		counts := cp.Counts()
+		for key := range counts {
+			counts[key] = -999
+		}
+	}
+	assert.True(t, clusters[0] == c.AllClusters(0)[0])
+	for _, cp := range c.AllClusters(0) {
+		for _, n := range cp.Counts() {
+			assert.True(t, n > 0)
+		}
+	}
+}
+
+type prioritizedPoint struct {
+	simplePoint
+	priority float64
+}
+
+func (pp prioritizedPoint) GetPriority() float64 { return pp.priority }
+
+func TestCluster_Position(t *testing.T) {
+	points := importData("./testdata/places.json")
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+
+	for _, position := range []ClusterPosition{PositionPriority, PositionNearest} {
+		c := NewCluster()
+		c.Position = position
+		c.ClusterPoints(geoPoints)
+
+		for _, cp := range c.AllClusters(0) {
+			if cp.NumPoints == 1 {
+				continue
+			}
+			leaves, _ := c.GetLeaves(cp.Id, 0, 0)
+			onLeaf := false
+			for _, leaf := range leaves {
+				coordinates := leaf.GetCoordinates()
+				if floatEquals(cp.X, coordinates.Lon) && math.Abs(cp.Y-coordinates.Lat) < 1e-6 {
+					onLeaf = true
+				}
+			}
+			assert.True(t, onLeaf, "cluster %v is not placed on its point", cp.Id)
+		}
+	}
+
+	c := NewCluster()
+	c.Position = PositionPriority
+	c.ClusterPoints([]GeoPoint{
+		prioritizedPoint{simplePoint{10, 10}, 1},
+		prioritizedPoint{simplePoint{10.1, 10}, 5},
+		prioritizedPoint{simplePoint{10.2, 10}, 2},
+	})
+	result := c.AllClusters(0)
+	assert.Equal(t, 1, len(result))
+	assert.True(t, floatEquals(10.1, result[0].X))
+}
+
+func TestCluster_PositionNearest(t *testing.T) {
+	points := importData("./testdata/places.json")
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	centroid := NewCluster()
+	centroid.ClusterPoints(geoPoints)
+	c := NewCluster()
+	c.Position = PositionNearest
+	c.ClusterPoints(geoPoints)
+
+	for z := 0; z <= 4; z++ {
+		clusters := c.AllClusters(z)
+		expected := centroid.AllClusters(z)
+		assert.Equal(t, len(expected), len(clusters))
+		for i, cp := range clusters {
+			//the same clusters, as the position doesn't affect clustering
+			assert.Equal(t, expected[i].Id, cp.Id)
+			assert.Equal(t, expected[i].NumPoints, cp.NumPoints)
+			//returned copies are not in the index, their coordinates are where they are displayed
+			px, py := cp.Coordinates()
+			assert.Equal(t, cp.X, px)
+			assert.Equal(t, cp.Y, py)
+			if cp.NumPoints == 1 {
+				continue
+			}
+			//brute force nearest leaf to the mean of all leaves, there could be several ones at the same distance
+			leaves, _ := c.GetLeaves(cp.Id, 0, 0)
+			var mx, my float64
+			for _, leaf := range leaves {
+				x, y := MercatorProjection(leaf.GetCoordinates())
+				mx += x / float64(len(leaves))
+				my += y / float64(len(leaves))
+			}
+			best := math.Inf(1)
+			onLeaf := false
+			for _, leaf := range leaves {
+				x, y := MercatorProjection(leaf.GetCoordinates())
+				best = math.Min(best, (x-mx)*(x-mx)+(y-my)*(y-my))
+				coordinates := leaf.GetCoordinates()
+				if floatEquals(cp.X, coordinates.Lon) && math.Abs(cp.Y-coordinates.Lat) < 1e-6 {
+					onLeaf = true
+				}
+			}
+			x, y := MercatorProjection(GeoCoordinates{Lon: cp.X, Lat: cp.Y})
+			assert.True(t, onLeaf, "cluster %v at zoom %v is not placed on its point", cp.Id, z)
+			assert.InDelta(t, best, (x-mx)*(x-mx)+(y-my)*(y-my), 1e-12, "cluster %v at zoom %v is not placed on the nearest point", cp.Id, z)
+		}
+	}
+}
+
+func TestCluster_ClusterIDs(t *testing.T) {
+	points := importData("./testdata/places.json")
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c1 := NewCluster()
+	c1.ClusterPoints(geoPoints)
+	c2 := NewCluster()
+	c2.ClusterPoints(geoPoints)
+
+	for z := c1.MinZoom; z <= c1.MaxZoom; z++ {
+		clusters := c1.AllClusters(z)
+		assert.Equal(t, clusters, c2.AllClusters(z))
+		for _, cp := range clusters {
+			if cp.NumPoints == 1 {
+				assert.True(t, cp.Id < len(geoPoints))
+				continue
+			}
+			assert.True(t, cp.Id > len(geoPoints))
+			originZoom, _, ok := c1.clusterOrigin(cp.Id)
+			assert.True(t, ok)
+			assert.True(t, originZoom > z)
+		}
+	}
+	_, _, ok := c1.clusterOrigin(len(geoPoints) + 100*32 + 1)
+	assert.False(t, ok)
+}
+
+func TestCluster_Supercluster(t *testing.T) {
+	points := importData("./testdata/places.json")
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c := NewCluster()
+	c.Supercluster = true
+	c.ClusterPoints(geoPoints)
+
+	result := c.GetTile(0, 0, 0)
+	expectedPoints := importGeoJSONResultFeature("./testdata/places-z0-0-0.json")
+	assert.Equal(t, len(expectedPoints), len(result))
+	for i, rp := range result {
+		ep := expectedPoints[i]
+		assert.Equal(t, ep.Geometry[0][0], rp.X)
+		assert.Equal(t, ep.Geometry[0][1], rp.Y)
+		if rp.NumPoints > 1 {
+			properties := c.properties(rp)
+			assert.Equal(t, ep.Tags.PointCount, properties["point_count"])
+			assert.EqualValues(t, ep.Tags.PointCountAbbreviated, properties["point_count_abbreviated"])
+		}
+	}
+
+	//points are indexed with float32 coordinates, single points are returned with the initial ones
+	for z := c.MinZoom; z <= c.MaxZoom+1; z++ {
+		for _, p := range c.Indexes[z].Points {
+			x, y := p.(*ClusterPoint).Coordinates()
+			assert.Equal(t, float64(float32(x)), x)
+			assert.Equal(t, float64(float32(y)), y)
+		}
+	}
+	for _, cp := range c.AllClusters(c.MaxZoom + 1) {
+		coordinates := geoPoints[cp.Id].GetCoordinates()
+		assert.True(t, floatEquals(coordinates.Lon, cp.X))
+		assert.True(t, floatEquals(coordinates.Lat, cp.Y))
+	}
+
+	//weights, categories and position are ignored
+	c = NewCluster()
+	c.Supercluster = true
+	c.Position = PositionPriority
+	c.ClusterPoints([]GeoPoint{
+		weightedPoint{simplePoint{10, 10}, 3},
+		weightedPoint{simplePoint{10.1, 10}, 1},
+		categorizedPoint{simplePoint{10.2, 10}, "hotel"},
+	})
+	clusters := c.AllClusters(0)
+	assert.Equal(t, 1, len(clusters))
+	assert.Equal(t, 3, clusters[0].NumPoints)
+	assert.Equal(t, 3.0, clusters[0].Weight)
+	assert.Equal(t, "", clusters[0].Category)
+	//cluster position is rounded to float32 in Mercator projection
+	assert.InDelta(t, 10.1, clusters[0].X, 1e-4)
+
+	assert.Equal(t, 999, abbreviateCount(999))
+	assert.Equal(t, "1k", abbreviateCount(1000))
+	assert.Equal(t, "1.6k", abbreviateCount(1550))
+	assert.Equal(t, "10k", abbreviateCount(9999))
+	assert.Equal(t, "12k", abbreviateCount(12345))
+	assert.Equal(t, -2, jsRound(-2.5))
+	assert.Equal(t, 3, jsRound(2.5))
+	assert.Equal(t, -2.0, c.roundPixel(-2.5))
+
+	//query-time clusters are built in the same mode
+	c = NewCluster()
+	c.Supercluster = true
+	c.ClusterPoints([]GeoPoint{
+		weightedPoint{simplePoint{10, 10}, 100},
+		weightedPoint{simplePoint{10.1, 10}, 1},
+	})
+	all := func(GeoPoint) bool { return true }
+	tile := c.GetTile(0, 0, 0)
+	filtered := c.GetTileFiltered(0, 0, 0, all)
+	assert.Equal(t, 1, len(tile))
+	assert.Equal(t, len(tile), len(filtered))
+	assert.Equal(t, tile[0].X, filtered[0].X)
+	assert.Equal(t, tile[0].Y, filtered[0].Y)
+	assert.Equal(t, 2.0, filtered[0].Weight)
+}
+
+func TestCluster_Seq(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	northWest := simplePoint{-71.01562500000001, 83.7539108491127}
+	southEast := simplePoint{71.36718750000001, -83.79204408779539}
+	for _, zoom := range []int{0, 2, 17} {
+		all := c.AllClusters(zoom)
+		assert.Equal(t, all, slices.Collect(c.AllClustersSeq(zoom)))
+		buffer := c.AppendAllClusters(make([]ClusterPoint, 1), zoom)
+		assert.Equal(t, all, buffer[1:])
+
+		clusters := c.GetClusters(northWest, southEast, zoom)
+		assert.Equal(t, clusters, slices.Collect(c.GetClustersSeq(northWest, southEast, zoom)))
+		assert.Equal(t, clusters, c.AppendClusters(buffer[:0], northWest, southEast, zoom))
+	}
+
+	n := 0
+	for range c.AllClustersSeq(17) {
+		n++
+		if n == 5 {
+			break
+		}
+	}
+	assert.Equal(t, 5, n)
+}
+
 func Test_MercatorProjection(t *testing.T) {
 	coor := GeoCoordinates{
 		Lon: -79.04411780507252, //0.2804330060970208
@@ -187,9 +752,13 @@ func ExampleCluster_GetTile() {
 	}
 
 	c.ClusterPoints(geoPoints)
-	result := c.GetTile(0, 0, 4)
-	fmt.Printf("%+v",result)
-	// Output: [{X:-2418 Y:165 zoom:0 Id:62 NumPoints:1} {X:-3350 Y:253 zoom:0 Id:22 NumPoints:1}]
+	result := c.GetTile(0, 3, 4)
+	for _, cp := range result {
+		fmt.Printf("{X:%v Y:%v Id:%v NumPoints:%v}\n", cp.X, cp.Y, cp.Id, cp.NumPoints)
+	}
+	// Output:
+	// {X:268 Y:100 Id:37 NumPoints:1}
+	// {X:150 Y:201 Id:36 NumPoints:1}
 
 }
 
@@ -204,12 +773,17 @@ func ExampleCluster_GetClusters() {
 	c := NewCluster()
 	c.ClusterPoints(geoPoints)
 
-	northWest := simplePoint{71.36718750000001, -83.79204408779539}
-	southEast := simplePoint{-71.01562500000001, 83.7539108491127}
+	northWest := simplePoint{-71.01562500000001, 83.7539108491127}
+	southEast := simplePoint{71.36718750000001, -83.79204408779539}
 
 	var result []ClusterPoint = c.GetClusters(northWest, southEast, 2)
-	fmt.Printf("%+v",result[:3])
-	// Output: [{X:-14.473194953510028 Y:26.157965399212813 zoom:1 Id:107 NumPoints:1} {X:-12.408741828510014 Y:58.16339752811905 zoom:1 Id:159 NumPoints:1} {X:-9.269962828651519 Y:42.928736057812586 zoom:1 Id:127 NumPoints:1}]
+	for _, cp := range result[:3] {
+		fmt.Printf("{X:%v Y:%v Id:%v NumPoints:%v}\n", cp.X, cp.Y, cp.Id, cp.NumPoints)
+	}
+	// Output:
+	// {X:-14.473194953510028 Y:26.157965399212813 Id:107 NumPoints:1}
+	// {X:-12.408741828510014 Y:58.16339752811905 Id:159 NumPoints:1}
+	// {X:-9.269962828651519 Y:42.928736057812586 Id:127 NumPoints:1}
 }
 
 ////Helpers
@@ -225,8 +799,9 @@ type TestPoint struct {
 	Type       string
 	Properties struct {
 		//we don't need other data
-		Name       string
-		PointCount int `json:"point_count"`
+		Name         string
+		PointCount   int    `json:"point_count"`
+		FeatureClass string `json:"featureclass"`
 	}
 	Geometry struct {
 		Coordinates []float64
@@ -236,7 +811,8 @@ type TestPoint struct {
 type GeoJSONResultFeature struct {
 	Geometry [][]float64
 	Tags     struct {
-		PointCount int `json:"point_count"`
+		PointCount            int         `json:"point_count"`
+		PointCountAbbreviated interface{} `json:"point_count_abbreviated"`
 	}
 }
 
@@ -247,6 +823,13 @@ func (tp *TestPoint) GetCoordinates() GeoCoordinates {
 	}
 }
 
+func (tp *TestPoint) GetProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"name":         tp.Properties.Name,
+		"featureclass": tp.Properties.FeatureClass,
+	}
+}
+
 func importData(filename string) []*TestPoint {
 	var points = struct {
 		Type     string
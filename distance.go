@@ -0,0 +1,116 @@
+package cluster
+
+import (
+	"math"
+	"sort"
+
+	"github.com/MadAppGang/kdbush"
+)
+
+// EarthRadius is the mean radius of the Earth in meters
+const EarthRadius = 6371008.8
+
+// GetClustersWithin returns the clusters and points for zoom level,
+// that are not farther than radiusMeters from the center.
+// Distance is geodesic, calculated on the sphere with EarthRadius.
+// X coordinate of returned object is Longitude and
+// Y coordinate of returned object is Latitude
+func (c *Cluster) GetClustersWithin(center GeoPoint, radiusMeters float64, zoom int) []ClusterPoint {
+	index := c.Indexes[c.limitZoom(zoom)]
+	found := within(index, center.GetCoordinates(), radiusMeters)
+	result := make([]ClusterPoint, len(found))
+	for i := range found {
+		result[i] = found[i].ClusterPoint
+	}
+	return result
+}
+
+// Nearest returns k nearest clusters and points for zoom level, ordered by geodesic distance to the point.
+// X coordinate of returned object is Longitude and
+// Y coordinate of returned object is Latitude
+func (c *Cluster) Nearest(point GeoPoint, k int, zoom int) []ClusterPoint {
+	index := c.Indexes[c.limitZoom(zoom)]
+	if k > len(index.Points) {
+		k = len(index.Points)
+	}
+	if k <= 0 {
+		return nil
+	}
+	coordinates := point.GetCoordinates()
+	//start from the cluster radius in meters and expand it, until it has k points inside
+	radius := 2 * math.Pi * EarthRadius * float64(c.PointSize) / float64(c.TileSize*(1<<uint(c.limitZoom(zoom))))
+	for {
+		found := within(index, coordinates, radius)
+		//the half of circumference covers all the Earth
+		if len(found) >= k || radius >= math.Pi*EarthRadius {
+			sort.SliceStable(found, func(i, j int) bool { return found[i].distance < found[j].distance })
+			result := make([]ClusterPoint, k)
+			for i := range result {
+				result[i] = found[i].ClusterPoint
+			}
+			return result
+		}
+		radius *= 4
+	}
+}
+
+// NearestLeaves returns k nearest initial points, ignoring clustering, ordered by geodesic distance to the point.
+// X coordinate of returned object is Longitude and
+// Y coordinate of returned object is Latitude
+func (c *Cluster) NearestLeaves(point GeoPoint, k int) []ClusterPoint {
+	return c.Nearest(point, k, c.MaxZoom+1)
+}
+
+//cluster point with LatLon coordinates and distance to some location
+type distancePoint struct {
+	ClusterPoint
+	distance float64
+}
+
+//find all points of the index, that are not farther than radiusMeters from the center
+func within(index *kdbush.KDBush, center GeoCoordinates, radiusMeters float64) []distancePoint {
+	center.Lon = normalizeLon(center.Lon)
+	west, south, east, north := boundingBox(center, radiusMeters)
+
+	var result []distancePoint
+	for _, id := range rangeBox(index, west, south, east, north) {
+		p := index.Points[id].(*ClusterPoint)
+		//the distance is measured to the position in the index, the point is returned where it's displayed
+		d := distance(center, ReverseMercatorProjection(p.Coordinates()))
+		if d > radiusMeters {
+			continue
+		}
+		cp := p.detached()
+		position := ReverseMercatorProjection(cp.X, cp.Y)
+		cp.X = position.Lon
+		cp.Y = position.Lat
+		result = append(result, distancePoint{cp, d})
+	}
+	return result
+}
+
+//box of the circle with the center and radius in meters on the sphere,
+//returns west, south, east and north, west is larger than east if the box is crossing 180 meridian
+func boundingBox(center GeoCoordinates, radiusMeters float64) (float64, float64, float64, float64) {
+	//angular radius
+	r := radiusMeters / EarthRadius
+	lat := center.Lat * math.Pi / 180
+	south := lat - r
+	north := lat + r
+	//circle contains a pole, so it covers all longitudes
+	if south <= -math.Pi/2 || north >= math.Pi/2 {
+		return -180, math.Max(south, -math.Pi/2) * 180 / math.Pi, 180, math.Min(north, math.Pi/2) * 180 / math.Pi
+	}
+	dLon := math.Asin(math.Sin(r)/math.Cos(lat)) * 180 / math.Pi
+	return normalizeLon(center.Lon - dLon), south * 180 / math.Pi, normalizeLon(center.Lon + dLon), north * 180 / math.Pi
+}
+
+//great-circle distance in meters between two points, haversine formula
+func distance(a, b GeoCoordinates) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	sinLat := math.Sin((lat2 - lat1) / 2)
+	sinLon := math.Sin((b.Lon - a.Lon) * math.Pi / 180 / 2)
+	h := sinLat*sinLat + math.Cos(lat1)*math.Cos(lat2)*sinLon*sinLon
+	return 2 * EarthRadius * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
@@ -0,0 +1,84 @@
+package cluster
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCluster_GetClustersWithin(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	cases := []struct {
+		center simplePoint
+		radius float64
+		zoom   int
+	}{
+		{simplePoint{-79.04, 43.08}, 1000000, 3},
+		{simplePoint{-79.04, 43.08}, 5000000, 17},
+		{simplePoint{179, -20}, 3000000, 5},
+		{simplePoint{-541, -20}, 3000000, 5},
+		{simplePoint{0, 85}, 2000000, 2},
+	}
+	for _, tc := range cases {
+		result := c.GetClustersWithin(tc.center, tc.radius, tc.zoom)
+		var expected []int
+		for _, cp := range c.AllClusters(tc.zoom) {
+			center := tc.center.GetCoordinates()
+			center.Lon = normalizeLon(center.Lon)
+			if distance(center, GeoCoordinates{Lon: cp.X, Lat: cp.Y}) <= tc.radius {
+				expected = append(expected, cp.Id)
+			}
+		}
+		var ids []int
+		for _, cp := range result {
+			ids = append(ids, cp.Id)
+		}
+		assert.NotEmpty(t, ids)
+		assert.ElementsMatch(t, expected, ids)
+	}
+}
+
+func TestCluster_Nearest(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	center := simplePoint{-79.04, 43.08}
+	for _, zoom := range []int{0, 4, 17} {
+		all := c.AllClusters(zoom)
+		sort.SliceStable(all, func(i, j int) bool {
+			return distance(center.GetCoordinates(), GeoCoordinates{Lon: all[i].X, Lat: all[i].Y}) <
+				distance(center.GetCoordinates(), GeoCoordinates{Lon: all[j].X, Lat: all[j].Y})
+		})
+		result := c.Nearest(center, 10, zoom)
+		assert.Equal(t, 10, len(result))
+		for i := range result {
+			assert.Equal(t, all[i].Id, result[i].Id)
+		}
+	}
+
+	assert.Equal(t, c.Nearest(center, 5, c.MaxZoom+1), c.NearestLeaves(center, 5))
+	assert.Equal(t, len(geoPoints), len(c.NearestLeaves(center, 1000)))
+	for _, cp := range c.NearestLeaves(center, 20) {
+		assert.Equal(t, 1, cp.NumPoints)
+	}
+}
+
+func Test_distance(t *testing.T) {
+	london := GeoCoordinates{Lon: -0.1278, Lat: 51.5074}
+	paris := GeoCoordinates{Lon: 2.3522, Lat: 48.8566}
+	assert.InDelta(t, 343500, distance(london, paris), 1000)
+	assert.InDelta(t, 0, distance(paris, paris), 1e-9)
+}
@@ -38,12 +38,17 @@
 // All ids of ClusterPoint that you have as result are the index of initial array of Geopoint,
 // so yu could get you point by this index
 //
-// Clusters of points are have autoincrement generated ids, started at ClusterIdxSeed
-// ClusterIdxSeed is the next power of length of input array
+// Clusters of points have ids larger than length of input array,
+// the id encodes the index of the point, the cluster was grown from, and the zoom level,
+// the same way MapBox's supercluster does:
+//	id = (originIndex << 5) + (zoom + 1) + len(points)
 //
-// For example, if input slice of points length is 78,  ClusterIdxSeed == 100,
-// if input slice of points length is 991,  ClusterIdxSeed == 1000
-// etc
+// So cluster ids are the same for every build of the same data
+//
+// GetClusters takes northWest as the west/north corner and southEast as the east/south corner,
+// if west longitude is larger than east one, the box is crossing 180 meridian.
+// Earlier versions required these corners swapped, such calls now silently return
+// the complementary box crossing 180 meridian, so the corners should be swapped back.
 //
 // TODO: Benchmarks
 //
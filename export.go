@@ -0,0 +1,162 @@
+package cluster
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// TileFormat is the encoding of exported tiles
+type TileFormat int
+
+const (
+	// FormatMVT encodes tiles to Mapbox Vector Tile with ExportOptions.MVT encoder
+	FormatMVT TileFormat = iota
+	// FormatJSON encodes tiles to JSON in geojson-vt format with ExportOptions.VT encoder
+	FormatJSON
+)
+
+// ExportOptions defines how the tiles are encoded by exporters
+// Format - encoding of tiles, FormatMVT by default
+// MVT - encoder of FormatMVT tiles, NewMVTEncoder() is used if it's nil
+// VT - encoder of FormatJSON tiles, NewVTEncoder() is used if it's nil
+// Gzip - compress every tile with gzip
+type ExportOptions struct {
+	Format TileFormat
+	MVT    *MVTEncoder
+	VT     *VTEncoder
+	Gzip   bool
+}
+
+//file extension of the tiles in the format
+func (o ExportOptions) extension() string {
+	if o.Format == FormatJSON {
+		return "json"
+	}
+	return "pbf"
+}
+
+//encode the tile with the options, returns nil if the tile is empty
+func (o ExportOptions) encode(c *Cluster, tile TileCoord) ([]byte, error) {
+	var data []byte
+	switch o.Format {
+	case FormatJSON:
+		encoder := o.VT
+		if encoder == nil {
+			encoder = NewVTEncoder()
+		}
+		points := c.getTile(tile.X, tile.Y, tile.Z, false, encoder.Extent)
+		if len(points) == 0 {
+			return nil, nil
+		}
+		var err error
+		data, err = json.Marshal(encoder.encode(c, points, 1))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		encoder := o.MVT
+		if encoder == nil {
+			encoder = NewMVTEncoder()
+		}
+		//points with pixel coordinates of MVT extent, as EncodeTile does
+		points := c.getTile(tile.X, tile.Y, tile.Z, false, encoder.Extent)
+		if len(points) == 0 {
+			return nil, nil
+		}
+		data = encoder.encode(c, points, 1)
+	}
+	if !o.Gzip {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportTileDir writes all non-empty tiles from MinZoom to MaxZoom+1 to outdir/{z}/{x}/{y}.pbf,
+// or to outdir/{z}/{x}/{y}.json for FormatJSON, creating the directories if needed.
+// Gzip-compressed tiles have the same names, they should be served with "Content-Encoding: gzip" header.
+// Returns the number of written tiles per zoom level, on error it's the number of tiles written before it.
+func (c *Cluster) ExportTileDir(outdir string, opts ExportOptions) (map[int]int, error) {
+	counts := make(map[int]int)
+	err := c.exportTiles(opts, func(tile TileCoord, data []byte) error {
+		dir := filepath.Join(outdir, strconv.Itoa(tile.Z), strconv.Itoa(tile.X))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		filename := filepath.Join(dir, strconv.Itoa(tile.Y)+"."+opts.extension())
+		if err := os.WriteFile(filename, data, 0644); err != nil {
+			return err
+		}
+		counts[tile.Z]++
+		return nil
+	})
+	return counts, err
+}
+
+//walk every non-empty tile from MinZoom to MaxZoom+1 and call fn with encoded tile
+//tiles of every zoom are walked by x and then by y
+func (c *Cluster) exportTiles(opts ExportOptions, fn func(tile TileCoord, data []byte) error) error {
+	for z := c.MinZoom; z <= c.MaxZoom+1; z++ {
+		for _, tile := range c.nonEmptyTiles(z) {
+			data, err := opts.encode(c, tile)
+			if err != nil {
+				return err
+			}
+			if data == nil {
+				continue
+			}
+			if err := fn(tile, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//tiles of zoom z, that could have points, sorted by x and y
+//every point is added to the tiles, whose buffer of PointSize contains it, the same way as GetTile finds them
+func (c *Cluster) nonEmptyTiles(z int) []TileCoord {
+	index := c.Indexes[c.limitZoom(z)]
+	z2 := 1 << uint(z)
+	p := float64(c.PointSize) / float64(c.TileSize)
+
+	found := make(map[TileCoord]bool)
+	for _, point := range index.Points {
+		//tiles select points by their position in the index, not where they are displayed
+		x, y := point.(*ClusterPoint).Coordinates()
+		x, y = x*float64(z2), y*float64(z2)
+		minY := int(math.Max(math.Ceil(y-1-p), 0))
+		maxY := int(math.Min(math.Floor(y+p), float64(z2-1)))
+		for tx := int(math.Ceil(x-1-p)); tx <= int(math.Floor(x+p)); tx++ {
+			for ty := minY; ty <= maxY; ty++ {
+				//tiles on the edges of the world include points from the other edge
+				found[TileCoord{X: (tx%z2 + z2) % z2, Y: ty, Z: z}] = true
+			}
+		}
+	}
+
+	result := make([]TileCoord, 0, len(found))
+	for tile := range found {
+		result = append(result, tile)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].X != result[j].X {
+			return result[i].X < result[j].X
+		}
+		return result[i].Y < result[j].Y
+	})
+	return result
+}
@@ -0,0 +1,88 @@
+package cluster
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCluster_ExportTileDir(t *testing.T) {
+	points := importData("./testdata/places.json")
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c := NewCluster()
+	c.MaxZoom = 3
+	c.ClusterPoints(geoPoints)
+
+	outdir := t.TempDir()
+	counts, err := c.ExportTileDir(outdir, ExportOptions{Format: FormatJSON, Gzip: true})
+	assert.NoError(t, err)
+
+	for z := c.MinZoom; z <= c.MaxZoom+1; z++ {
+		n := 0
+		for x := 0; x < 1<<uint(z); x++ {
+			for y := 0; y < 1<<uint(z); y++ {
+				filename := filepath.Join(outdir, strconv.Itoa(z), strconv.Itoa(x), strconv.Itoa(y)+".json")
+				raw, err := os.ReadFile(filename)
+				if len(c.GetTile(x, y, z)) == 0 {
+					assert.True(t, os.IsNotExist(err), "empty tile %v/%v/%v is written", z, x, y)
+					continue
+				}
+				n++
+				assert.NoError(t, err)
+				zr, err := gzip.NewReader(bytes.NewReader(raw))
+				assert.NoError(t, err)
+				data, err := io.ReadAll(zr)
+				assert.NoError(t, err)
+				expected, _ := json.Marshal(c.GetVTTile(x, y, z))
+				assert.Equal(t, expected, data)
+			}
+		}
+		assert.Equal(t, n, counts[z], "zoom %v", z)
+	}
+}
+
+func TestCluster_ExportTileDirPosition(t *testing.T) {
+	//the cluster at zoom 5 is placed on the western point, but its mean is closer
+	//than PointSize to the tile east of 0 meridian, so the tile has the cluster
+	c := NewCluster()
+	c.MaxZoom = 5
+	c.Position = PositionPriority
+	c.ClusterPoints([]GeoPoint{
+		prioritizedPoint{simplePoint{-0.9668, 0}, 1},
+		prioritizedPoint{simplePoint{-1.8018, 0}, 5},
+		prioritizedPoint{simplePoint{-0.1318, 0}, 1},
+		prioritizedPoint{simplePoint{-0.1318, 0}, 1},
+	})
+	assert.Equal(t, 1, len(c.GetTile(16, 16, 5)))
+
+	outdir := t.TempDir()
+	counts, err := c.ExportTileDir(outdir, ExportOptions{Format: FormatJSON})
+	assert.NoError(t, err)
+
+	for z := c.MinZoom; z <= c.MaxZoom+1; z++ {
+		n := 0
+		for x := 0; x < 1<<uint(z); x++ {
+			for y := 0; y < 1<<uint(z); y++ {
+				filename := filepath.Join(outdir, strconv.Itoa(z), strconv.Itoa(x), strconv.Itoa(y)+".json")
+				_, err := os.Stat(filename)
+				if len(c.GetTile(x, y, z)) == 0 {
+					assert.True(t, os.IsNotExist(err), "empty tile %v/%v/%v is written", z, x, y)
+					continue
+				}
+				n++
+				assert.NoError(t, err, "tile %v/%v/%v is not written", z, x, y)
+			}
+		}
+		assert.Equal(t, n, counts[z], "zoom %v", z)
+	}
+}
@@ -0,0 +1,53 @@
+package cluster
+
+import (
+	"github.com/MadAppGang/kdbush"
+)
+
+// GetClustersFiltered returns the array of clusters for zoom level, the same way as GetClusters,
+// but only for the initial points, that match the filter.
+// Clusters are taken from the index and recounted from their matching initial points at query time,
+// clusters without such points are skipped, cluster with one matching point is returned as that point.
+// Clusters keep their positions in the index, the index is not rebuilt.
+// Ids of single points are the indexes of initial array of GeoPoint,
+// recounted clusters are not stored in the index, so their Id is -1.
+func (c *Cluster) GetClustersFiltered(northWest, southEast GeoPoint, zoom int, filter func(GeoPoint) bool) []ClusterPoint {
+	index, ids := c.clustersInBox(northWest, southEast, zoom)
+	ids, points := c.filterPoints(ids, index.Points, filter)
+	return appendLatLonPoints(make([]ClusterPoint, 0, len(ids)), ids, points)
+}
+
+// GetTileFiltered returns points for tile with coordinates x and y and for zoom z, the same way as GetTile,
+// but only for the initial points, that match the filter, see GetClustersFiltered for details.
+func (c *Cluster) GetTileFiltered(x, y, z int, filter func(GeoPoint) bool) []ClusterPoint {
+	return c.getTileFiltered(x, y, z, false, c.TileSize, filter)
+}
+
+//points with ids, that have initial points matching the filter, returned as the new points and their ids,
+//clusters are recounted from the matching initial points,
+//ids and points are returned as they are, if filter is nil
+func (c *Cluster) filterPoints(ids []int, points []kdbush.Point, filter func(GeoPoint) bool) ([]int, []kdbush.Point) {
+	if filter == nil {
+		return ids, points
+	}
+	match := func(leaf *ClusterPoint) bool { return filter(c.Points[leaf.Id]) }
+	filtered := make([]kdbush.Point, 0, len(ids))
+	for _, id := range ids {
+		cp := points[id].(*ClusterPoint).detached()
+		if cp.Id < len(c.Points) {
+			if !filter(c.Points[cp.Id]) {
+				continue
+			}
+		} else if !c.recount(&cp, match) {
+			continue
+		} else if cp.NumPoints > 1 {
+			cp.Id = -1
+		}
+		filtered = append(filtered, &cp)
+	}
+	result := make([]int, len(filtered))
+	for i := range result {
+		result[i] = i
+	}
+	return result, filtered
+}
@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCluster_GetTileFiltered(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	all := func(GeoPoint) bool { return true }
+	expected := c.GetTile(0, 0, 0)
+	result := c.GetTileFiltered(0, 0, 0, all)
+	assert.Equal(t, len(expected), len(result))
+	for i := range result {
+		assert.Equal(t, expected[i].X, result[i].X)
+		assert.Equal(t, expected[i].Y, result[i].Y)
+		assert.Equal(t, expected[i].NumPoints, result[i].NumPoints)
+		if result[i].NumPoints == 1 {
+			assert.Equal(t, expected[i].Id, result[i].Id)
+		} else {
+			assert.Equal(t, -1, result[i].Id)
+		}
+	}
+
+	assert.Empty(t, c.GetTileFiltered(0, 0, 0, func(GeoPoint) bool { return false }))
+}
+
+func TestCluster_GetClustersFiltered(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	c.CountBy = func(p GeoPoint) string { return p.(*TestPoint).Properties.FeatureClass }
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	waterfalls := 0
+	for _, p := range points {
+		if p.Properties.FeatureClass == "waterfall" {
+			waterfalls++
+		}
+	}
+	isWaterfall := func(p GeoPoint) bool { return p.(*TestPoint).Properties.FeatureClass == "waterfall" }
+
+	for _, zoom := range []int{0, 3, 17} {
+		result := c.GetClustersFiltered(simplePoint{-180, 85}, simplePoint{180, -85}, zoom, isWaterfall)
+		numPoints := 0
+		for _, cp := range result {
+			numPoints += cp.NumPoints
+			if cp.NumPoints == 1 {
+				assert.True(t, isWaterfall(geoPoints[cp.Id]))
+			} else {
+				assert.Equal(t, map[string]int{"waterfall": cp.NumPoints}, cp.Counts())
+			}
+		}
+		assert.Equal(t, waterfalls, numPoints)
+	}
+
+	//the only matching point is returned as the point, not as the cluster it's merged into
+	first := func(p GeoPoint) bool { return p == geoPoints[0] }
+	result := c.GetClustersFiltered(simplePoint{-180, 85}, simplePoint{180, -85}, 0, first)
+	assert.Equal(t, 1, len(result))
+	assert.Equal(t, 0, result[0].Id)
+	assert.Equal(t, 1, result[0].NumPoints)
+	assert.InDelta(t, points[0].GetCoordinates().Lon, result[0].X, 1e-9)
+}
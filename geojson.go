@@ -0,0 +1,64 @@
+package cluster
+
+// FeatureCollection is RFC 7946 GeoJSON FeatureCollection of clusters and points
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is GeoJSON Feature of the cluster or the single point
+// Clusters have cluster, cluster_id and point_count properties, and Aggregated values, if it's map[string]interface{}.
+// In Cluster's Supercluster mode clusters have point_count_abbreviated property as well.
+// Single points have the properties of the point, if it implements AttributedGeoPoint.
+// ID is cluster id, it's omitted for single points
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         int                    `json:"id,omitempty"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry is GeoJSON Point geometry, Coordinates are longitude and latitude
+type Geometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// GeoJSON converts points with LatLon coordinates, returned by GetClusters, AllClusters or GetTileWithLatLon,
+// to GeoJSON FeatureCollection. It could be marshaled with encoding/json.
+func (c *Cluster) GeoJSON(points []ClusterPoint) FeatureCollection {
+	result := FeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]Feature, len(points)),
+	}
+	for i, cp := range points {
+		feature := Feature{
+			Type: "Feature",
+			Geometry: Geometry{
+				Type:        "Point",
+				Coordinates: []float64{cp.X, cp.Y},
+			},
+			Properties: c.properties(cp),
+		}
+		if cp.NumPoints > 1 && cp.Id > 0 {
+			feature.ID = cp.Id
+		}
+		result.Features[i] = feature
+	}
+	return result
+}
+
+// GetClustersGeoJSON returns the result of GetClusters as GeoJSON FeatureCollection
+func (c *Cluster) GetClustersGeoJSON(northWest, southEast GeoPoint, zoom int) FeatureCollection {
+	return c.GeoJSON(c.GetClusters(northWest, southEast, zoom))
+}
+
+// AllClustersGeoJSON returns the result of AllClusters as GeoJSON FeatureCollection
+func (c *Cluster) AllClustersGeoJSON(zoom int) FeatureCollection {
+	return c.GeoJSON(c.AllClusters(zoom))
+}
+
+// GetTileGeoJSON returns the result of GetTileWithLatLon as GeoJSON FeatureCollection
+func (c *Cluster) GetTileGeoJSON(x, y, z int) FeatureCollection {
+	return c.GeoJSON(c.GetTileWithLatLon(x, y, z))
+}
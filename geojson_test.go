@@ -0,0 +1,58 @@
+package cluster
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCluster_GeoJSON(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	northWest := simplePoint{-71.01562500000001, 83.7539108491127}
+	southEast := simplePoint{71.36718750000001, -83.79204408779539}
+	clusters := c.GetClusters(northWest, southEast, 2)
+	raw, err := json.Marshal(c.GetClustersGeoJSON(northWest, southEast, 2))
+	assert.NoError(t, err)
+
+	var collection struct {
+		Type     string
+		Features []struct {
+			Type     string
+			ID       *int
+			Geometry struct {
+				Type        string
+				Coordinates []float64
+			}
+			Properties map[string]interface{}
+		}
+	}
+	assert.NoError(t, json.Unmarshal(raw, &collection))
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	assert.Equal(t, len(clusters), len(collection.Features))
+	for i, f := range collection.Features {
+		cp := clusters[i]
+		assert.Equal(t, "Feature", f.Type)
+		assert.Equal(t, "Point", f.Geometry.Type)
+		assert.Equal(t, []float64{cp.X, cp.Y}, f.Geometry.Coordinates)
+		if cp.NumPoints > 1 {
+			assert.Equal(t, cp.Id, *f.ID)
+			assert.Equal(t, true, f.Properties["cluster"])
+			assert.Equal(t, float64(cp.Id), f.Properties["cluster_id"])
+			assert.Equal(t, float64(cp.NumPoints), f.Properties["point_count"])
+		} else {
+			assert.Nil(t, f.ID)
+			assert.Equal(t, points[cp.Id].Properties.Name, f.Properties["name"])
+		}
+	}
+
+	assert.Equal(t, len(c.AllClusters(0)), len(c.AllClustersGeoJSON(0).Features))
+	assert.Equal(t, len(c.GetTileWithLatLon(0, 0, 0)), len(c.GetTileGeoJSON(0, 0, 0).Features))
+}
@@ -0,0 +1,235 @@
+package cluster
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// MVTEncoder encodes tile points to Mapbox Vector Tile protobuf with one layer of point features.
+// LayerName - name of the layer
+// Extent - tile extent of MVT coordinates, pixel coordinates of tile points are scaled from Cluster's TileSize to it
+//
+// Clusters have cluster, cluster_id and point_count attributes, and Aggregated values, if it's map[string]interface{}.
+// In Cluster's Supercluster mode clusters have point_count_abbreviated attribute as well.
+// Single points have the properties of the point, if it implements AttributedGeoPoint.
+type MVTEncoder struct {
+	LayerName string
+	Extent    int
+}
+
+// NewMVTEncoder creates new MVTEncoder with default parameters:
+// LayerName = "clusters"
+// Extent = 4096
+func NewMVTEncoder() *MVTEncoder {
+	return &MVTEncoder{
+		LayerName: "clusters",
+		Extent:    4096,
+	}
+}
+
+// EncodeTile encodes points of GetTile(x, y, z) result
+// Coordinates are calculated for Extent from the positions of points, so larger Extent is more precise
+func (e *MVTEncoder) EncodeTile(c *Cluster, x, y, z int) []byte {
+	return e.encode(c, c.getTile(x, y, z, false, e.Extent), 1)
+}
+
+// Encode encodes tile points with pixel coordinates, the result of GetTile or GetTileFiltered
+// Pixel coordinates are scaled from Cluster's TileSize to Extent, so they are not more precise than TileSize
+func (e *MVTEncoder) Encode(c *Cluster, points []ClusterPoint) []byte {
+	return e.encode(c, points, float64(e.Extent)/float64(c.TileSize))
+}
+
+//encode tile points, pixel coordinates are multiplied by scale to get coordinates for Extent
+func (e *MVTEncoder) encode(c *Cluster, points []ClusterPoint, scale float64) []byte {
+	var keys []string
+	keyIdx := make(map[string]int)
+	var values [][]byte
+	valueIdx := make(map[string]int)
+
+	var layer []byte
+	layer = appendPBFString(layer, 1, e.LayerName)
+	for _, cp := range points {
+		var feature []byte
+		if cp.Id >= 0 {
+			feature = appendPBFVarint(feature, 1, uint64(cp.Id))
+		}
+
+		properties := c.properties(cp)
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var tags []uint64
+		for _, name := range names {
+			value, ok := encodeMVTValue(properties[name])
+			if !ok {
+				continue
+			}
+			ki, ok := keyIdx[name]
+			if !ok {
+				ki = len(keys)
+				keyIdx[name] = ki
+				keys = append(keys, name)
+			}
+			vi, ok := valueIdx[string(value)]
+			if !ok {
+				vi = len(values)
+				valueIdx[string(value)] = vi
+				values = append(values, value)
+			}
+			tags = append(tags, uint64(ki), uint64(vi))
+		}
+		if len(tags) > 0 {
+			feature = appendPBFPacked(feature, 2, tags)
+		}
+		//point geometry type
+		feature = appendPBFVarint(feature, 3, 1)
+		//MoveTo command with one point
+		x := int64(math.Round(cp.X * scale))
+		y := int64(math.Round(cp.Y * scale))
+		feature = appendPBFPacked(feature, 4, []uint64{1 | 1<<3, zigzag(x), zigzag(y)})
+
+		layer = appendPBFBytes(layer, 2, feature)
+	}
+	for _, key := range keys {
+		layer = appendPBFString(layer, 3, key)
+	}
+	for _, value := range values {
+		layer = appendPBFBytes(layer, 4, value)
+	}
+	layer = appendPBFVarint(layer, 5, uint64(e.Extent))
+	layer = appendPBFVarint(layer, 15, 2)
+
+	return appendPBFBytes(nil, 3, layer)
+}
+
+//feature properties of the cluster or single point
+func (c *Cluster) properties(cp ClusterPoint) map[string]interface{} {
+	properties := make(map[string]interface{})
+	if cp.NumPoints > 1 {
+		if aggregated, ok := cp.Aggregated.(map[string]interface{}); ok {
+			for name, value := range aggregated {
+				properties[name] = value
+			}
+		}
+		properties["cluster"] = true
+		properties["cluster_id"] = cp.Id
+		properties["point_count"] = cp.NumPoints
+		if c.Supercluster {
+			properties["point_count_abbreviated"] = abbreviateCount(cp.NumPoints)
+		}
+		return properties
+	}
+	if cp.Id >= 0 && cp.Id < len(c.Points) {
+		if ap, ok := c.Points[cp.Id].(AttributedGeoPoint); ok {
+			for name, value := range ap.GetProperties() {
+				properties[name] = value
+			}
+		}
+	}
+	return properties
+}
+
+//abbreviated number of points, the same as supercluster's point_count_abbreviated:
+//the number itself below 1000, and thousands with "k" suffix, like "1.5k" or "12k", for larger numbers
+func abbreviateCount(n int) interface{} {
+	switch {
+	case n >= 10000:
+		return strconv.Itoa(jsRound(float64(n)/1000)) + "k"
+	case n >= 1000:
+		return strconv.FormatFloat(float64(jsRound(float64(n)/100))/10, 'f', -1, 64) + "k"
+	}
+	return n
+}
+
+//encode MVT Value message, the same way as vt-pbf does:
+//integer numbers are uint or sint values, other numbers are double values
+//values of other types are encoded as JSON strings
+func encodeMVTValue(value interface{}) ([]byte, bool) {
+	if value == nil {
+		return nil, false
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String:
+		return appendPBFString(nil, 1, v.String()), true
+	case reflect.Bool:
+		b := uint64(0)
+		if v.Bool() {
+			b = 1
+		}
+		return appendPBFVarint(nil, 7, b), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < 0 {
+			return appendPBFVarint(nil, 6, zigzag(v.Int())), true
+		}
+		return appendPBFVarint(nil, 5, uint64(v.Int())), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return appendPBFVarint(nil, 5, v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+			if f < 0 {
+				return appendPBFVarint(nil, 6, zigzag(int64(f))), true
+			}
+			return appendPBFVarint(nil, 5, uint64(f)), true
+		}
+		return appendPBFFixed64(nil, 3, math.Float64bits(f)), true
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	return appendPBFString(nil, 1, string(raw)), true
+}
+
+/////////////////////////////////
+// protobuf encoding
+/////////////////////////////////
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func appendPBFVarint(b []byte, field int, v uint64) []byte {
+	b = appendVarint(b, uint64(field<<3))
+	return appendVarint(b, v)
+}
+
+func appendPBFFixed64(b []byte, field int, v uint64) []byte {
+	b = appendVarint(b, uint64(field<<3|1))
+	for i := 0; i < 8; i++ {
+		b = append(b, byte(v>>(8*uint(i))))
+	}
+	return b
+}
+
+func appendPBFBytes(b []byte, field int, data []byte) []byte {
+	b = appendVarint(b, uint64(field<<3|2))
+	b = appendVarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendPBFString(b []byte, field int, s string) []byte {
+	return appendPBFBytes(b, field, []byte(s))
+}
+
+func appendPBFPacked(b []byte, field int, values []uint64) []byte {
+	var data []byte
+	for _, v := range values {
+		data = appendVarint(data, v)
+	}
+	return appendPBFBytes(b, field, data)
+}
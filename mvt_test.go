@@ -0,0 +1,166 @@
+package cluster
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMVTEncoder_EncodeTile(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	e := NewMVTEncoder()
+	e.LayerName = "places"
+	e.Extent = 1024
+	tile := c.GetTile(0, 0, 1)
+	positions := c.GetTileWithLatLon(0, 0, 1)
+	data := e.EncodeTile(c, 0, 0, 1)
+
+	layers := readPBF(data)
+	assert.Equal(t, 1, len(layers))
+	assert.Equal(t, 3, layers[0].num)
+
+	var name string
+	var keys []string
+	var values []interface{}
+	var features [][]pbfField
+	var extent, version uint64
+	for _, f := range readPBF(layers[0].data) {
+		switch f.num {
+		case 1:
+			name = string(f.data)
+		case 2:
+			features = append(features, readPBF(f.data))
+		case 3:
+			keys = append(keys, string(f.data))
+		case 4:
+			v := readPBF(f.data)[0]
+			switch v.num {
+			case 1:
+				values = append(values, string(v.data))
+			case 5:
+				values = append(values, int(v.v))
+			case 7:
+				values = append(values, v.v == 1)
+			default:
+				values = append(values, v.v)
+			}
+		case 5:
+			extent = f.v
+		case 15:
+			version = f.v
+		}
+	}
+	assert.Equal(t, "places", name)
+	assert.Equal(t, uint64(1024), extent)
+	assert.Equal(t, uint64(2), version)
+	assert.Equal(t, len(tile), len(features))
+
+	for i, feature := range features {
+		cp := tile[i]
+		properties := map[string]interface{}{}
+		for _, f := range feature {
+			switch f.num {
+			case 1:
+				assert.Equal(t, uint64(cp.Id), f.v)
+			case 2:
+				tags := readPacked(f.data)
+				for j := 0; j < len(tags); j += 2 {
+					properties[keys[tags[j]]] = values[tags[j+1]]
+				}
+			case 3:
+				assert.Equal(t, uint64(1), f.v)
+			case 4:
+				//coordinates are calculated for the extent, not scaled from pixels of TileSize
+				x, y := MercatorProjection(GeoCoordinates{Lon: positions[i].X, Lat: positions[i].Y})
+				//points from the other side of the world are shifted by the world size
+				shift := math.Round((x*2*512 - cp.X) / 512)
+				geometry := readPacked(f.data)
+				assert.Equal(t, []uint64{9, zigzag(int64(math.Round((x*2 - shift) * 1024))), zigzag(int64(math.Round(y * 2 * 1024)))}, geometry)
+			}
+		}
+		if cp.NumPoints > 1 {
+			assert.Equal(t, true, properties["cluster"])
+			assert.Equal(t, cp.Id, properties["cluster_id"])
+			assert.Equal(t, cp.NumPoints, properties["point_count"])
+		} else {
+			assert.Equal(t, points[cp.Id].Properties.Name, properties["name"])
+		}
+	}
+
+	//Encode scales pixel coordinates of the points
+	features = nil
+	for _, f := range readPBF(readPBF(e.Encode(c, tile))[0].data) {
+		if f.num == 2 {
+			features = append(features, readPBF(f.data))
+		}
+	}
+	assert.Equal(t, len(tile), len(features))
+	for i, feature := range features {
+		for _, f := range feature {
+			if f.num == 4 {
+				cp := tile[i]
+				assert.Equal(t, []uint64{9, zigzag(int64(cp.X * 2)), zigzag(int64(cp.Y * 2))}, readPacked(f.data))
+			}
+		}
+	}
+}
+
+type pbfField struct {
+	num  int
+	v    uint64
+	data []byte
+}
+
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(b); i++ {
+		v |= uint64(b[i]&0x7f) << (7 * uint(i))
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return v, len(b)
+}
+
+func readPBF(b []byte) []pbfField {
+	var result []pbfField
+	for len(b) > 0 {
+		key, n := readVarint(b)
+		b = b[n:]
+		f := pbfField{num: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			f.v, n = readVarint(b)
+			b = b[n:]
+		case 1:
+			for i := 0; i < 8; i++ {
+				f.v |= uint64(b[i]) << (8 * uint(i))
+			}
+			b = b[8:]
+		case 2:
+			l, n := readVarint(b)
+			f.data = b[n : n+int(l)]
+			b = b[n+int(l):]
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+func readPacked(b []byte) []uint64 {
+	var result []uint64
+	for len(b) > 0 {
+		v, n := readVarint(b)
+		result = append(result, v)
+		b = b[n:]
+	}
+	return result
+}
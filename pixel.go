@@ -0,0 +1,56 @@
+package cluster
+
+import "math"
+
+// HitTest returns the cluster or point under the pixel position px, py of the tile x, y, z.
+// PointSize is used as marker radius, if there are several markers under the position, the nearest one is returned.
+// Returned object has pixel coordinates, the same as GetTile.
+// The second value is false, if there is no marker under the position.
+func (c *Cluster) HitTest(x, y, z int, px, py float64) (ClusterPoint, bool) {
+	r := float64(c.PointSize)
+	var result ClusterPoint
+	found := false
+	best := r * r
+	for _, cp := range c.GetTile(x, y, z) {
+		d := (cp.X-px)*(cp.X-px) + (cp.Y-py)*(cp.Y-py)
+		if d <= best {
+			result = cp
+			best = d
+			found = true
+		}
+	}
+	return result, found
+}
+
+// GetViewport returns clusters and points visible on the map viewport with the center, zoom and size in pixels.
+// Cluster level is chosen for integer part of the zoom.
+// Markers outside of the viewport, but closer than PointSize to its border, are returned as well.
+// Returned objects have pixel coordinates relative to the top left corner of the viewport.
+func (c *Cluster) GetViewport(center GeoCoordinates, zoom float64, widthPx, heightPx int) []ClusterPoint {
+	index := c.Indexes[c.limitZoom(int(math.Floor(zoom)))]
+	worldSize := float64(c.TileSize) * math.Pow(2, zoom)
+	center.Lon = normalizeLon(center.Lon)
+	cx, cy := MercatorProjection(center)
+
+	//top left corner of the viewport in pixels of the world
+	left := cx*worldSize - float64(widthPx)/2
+	top := cy*worldSize - float64(heightPx)/2
+	pad := float64(c.PointSize)
+	minX := (left - pad) / worldSize
+	maxX := (left + float64(widthPx) + pad) / worldSize
+	minY := (top - pad) / worldSize
+	maxY := (top + float64(heightPx) + pad) / worldSize
+
+	var result []ClusterPoint
+	//viewport could show several copies of the world
+	for shift := math.Floor(minX); shift <= math.Floor(maxX); shift++ {
+		ids := index.Range(math.Max(minX-shift, 0), minY, math.Min(maxX-shift, 1), maxY)
+		for _, id := range ids {
+			cp := index.Points[id].(*ClusterPoint).detached()
+			cp.X = c.roundPixel((cp.X+shift)*worldSize - left)
+			cp.Y = c.roundPixel(cp.Y*worldSize - top)
+			result = append(result, cp)
+		}
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCluster_HitTest(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	tile := c.GetTile(0, 0, 1)
+	assert.NotEmpty(t, tile)
+	for _, cp := range tile {
+		hit, ok := c.HitTest(0, 0, 1, cp.X+1, cp.Y-1)
+		assert.True(t, ok)
+		d := (hit.X-cp.X-1)*(hit.X-cp.X-1) + (hit.Y-cp.Y+1)*(hit.Y-cp.Y+1)
+		assert.True(t, d <= 2)
+	}
+
+	_, ok := c.HitTest(0, 0, 1, -1000, -1000)
+	assert.False(t, ok)
+}
+
+func TestCluster_GetViewport(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	//viewport of the tile size, centered to the tile, is the same as tile
+	center := ReverseMercatorProjection(1.5/4, 1.5/4)
+	tile := c.GetTile(1, 1, 2)
+	result := c.GetViewport(center, 2, c.TileSize, c.TileSize)
+	assert.NotEmpty(t, result)
+	assert.Equal(t, len(tile), len(result))
+	for i := range result {
+		assert.Equal(t, tile[i].Id, result[i].Id)
+		assert.Equal(t, tile[i].X, result[i].X)
+		assert.Equal(t, tile[i].Y, result[i].Y)
+	}
+
+	//viewport larger than the world shows the world copies
+	width, height := 3*c.TileSize, 2*c.TileSize
+	result = c.GetViewport(GeoCoordinates{Lon: 170, Lat: 0}, 0.5, width, height)
+	copies := map[int]int{}
+	for _, cp := range result {
+		copies[cp.Id]++
+		assert.True(t, cp.X >= -float64(c.PointSize) && cp.X <= float64(width+c.PointSize))
+		assert.True(t, cp.Y >= -float64(c.PointSize) && cp.Y <= float64(height+c.PointSize))
+	}
+	assert.Equal(t, len(c.AllClusters(0)), len(copies))
+	for _, n := range copies {
+		assert.True(t, n >= 2)
+	}
+}
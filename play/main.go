@@ -75,8 +75,8 @@ func main() {
 	c.MaxZoom = 3
 	c.TileSize = 256
 	//c.NodeSize = 64
-	northWest := simplePoint{ 71.36718750000001, -83.79204408779539}
-	southEast := simplePoint{-71.01562500000001,  83.7539108491127 }
+	northWest := simplePoint{-71.01562500000001, 83.7539108491127}
+	southEast := simplePoint{71.36718750000001, -83.79204408779539}
 	c.ClusterPoints(geoPoints)
 
 	result :=c.GetClusters(northWest, southEast, 2)
@@ -0,0 +1,266 @@
+package cluster
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+//PMTiles v3 archive constants, see https://github.com/protomaps/PMTiles/blob/main/spec/v3/spec.md
+const (
+	pmtilesHeaderSize      = 127
+	pmtilesRootSize        = 16384 //header and root directory should fit in the first 16 KB
+	pmtilesCompressionNone = 1
+	pmtilesCompressionGzip = 2
+	pmtilesTypeUnknown     = 0
+	pmtilesTypeMVT         = 1
+)
+
+//entry of PMTiles directory, tile data or leaf directory
+type pmtilesEntry struct {
+	TileID    uint64
+	Offset    uint64
+	Length    uint64
+	RunLength uint64
+}
+
+// ExportPMTiles writes all non-empty tiles from MinZoom to MaxZoom+1 to PMTiles v3 archive file,
+// see WritePMTiles for details
+func (c *Cluster) ExportPMTiles(filename string, opts ExportOptions) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := c.WritePMTiles(f, opts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WritePMTiles encodes all non-empty tiles from MinZoom to MaxZoom+1 with opts
+// and writes them as PMTiles v3 archive, that could be served from a plain file server.
+// Tile data is ordered by tile id, identical tiles are stored once. Directories are not compressed,
+// tiles are gzip-compressed, if opts.Gzip is set.
+// Metadata has vector_layers of the MVT layer, if the format is FormatMVT.
+// The archive is built in memory before it's written to w.
+func (c *Cluster) WritePMTiles(w io.Writer, opts ExportOptions) error {
+	type tile struct {
+		id   uint64
+		data []byte
+	}
+	var tiles []tile
+	err := c.exportTiles(opts, func(t TileCoord, data []byte) error {
+		tiles = append(tiles, tile{pmtilesTileID(t.Z, t.X, t.Y), data})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	//tile data is written in the order of tile ids, tiles are walked by x and y inside the zoom
+	sort.Slice(tiles, func(i, j int) bool { return tiles[i].id < tiles[j].id })
+
+	var entries []pmtilesEntry
+	var tileData bytes.Buffer
+	contents := make(map[[sha256.Size]byte]pmtilesEntry)
+	for _, t := range tiles {
+		hash := sha256.Sum256(t.data)
+		content, ok := contents[hash]
+		if !ok {
+			content = pmtilesEntry{Offset: uint64(tileData.Len()), Length: uint64(len(t.data))}
+			contents[hash] = content
+			tileData.Write(t.data)
+		}
+		//the same content for the next tile id is stored as the run of the previous entry
+		if n := len(entries); n > 0 {
+			last := &entries[n-1]
+			if last.Offset == content.Offset && last.TileID+last.RunLength == t.id {
+				last.RunLength++
+				continue
+			}
+		}
+		entries = append(entries, pmtilesEntry{TileID: t.id, Offset: content.Offset, Length: content.Length, RunLength: 1})
+	}
+
+	metadata, err := json.Marshal(c.pmtilesMetadata(opts))
+	if err != nil {
+		return err
+	}
+	root, leaves := pmtilesDirectories(entries, pmtilesRootSize-pmtilesHeaderSize)
+
+	header := make([]byte, pmtilesHeaderSize)
+	copy(header, "PMTiles")
+	header[7] = 3
+	offset := uint64(pmtilesHeaderSize)
+	for i, length := range []int{len(root), len(metadata), len(leaves), tileData.Len()} {
+		binary.LittleEndian.PutUint64(header[8+i*16:], offset)
+		binary.LittleEndian.PutUint64(header[16+i*16:], uint64(length))
+		offset += uint64(length)
+	}
+	binary.LittleEndian.PutUint64(header[72:], uint64(len(tiles)))
+	binary.LittleEndian.PutUint64(header[80:], uint64(len(entries)))
+	binary.LittleEndian.PutUint64(header[88:], uint64(len(contents)))
+	header[96] = 1 //tile data is ordered by tile id
+	header[97] = pmtilesCompressionNone
+	header[98] = pmtilesCompressionNone
+	if opts.Gzip {
+		header[98] = pmtilesCompressionGzip
+	}
+	header[99] = pmtilesTypeUnknown
+	if opts.Format == FormatMVT {
+		header[99] = pmtilesTypeMVT
+	}
+	header[100] = byte(c.MinZoom)
+	header[101] = byte(c.MaxZoom + 1)
+	west, south, east, north := c.bounds()
+	for i, v := range []float64{west, south, east, north} {
+		binary.LittleEndian.PutUint32(header[102+i*4:], uint32(int32(math.Round(v*1e7))))
+	}
+	header[118] = byte(c.MinZoom)
+	binary.LittleEndian.PutUint32(header[119:], uint32(int32(math.Round((west+east)/2*1e7))))
+	binary.LittleEndian.PutUint32(header[123:], uint32(int32(math.Round((south+north)/2*1e7))))
+
+	for _, part := range [][]byte{header, root, metadata, leaves, tileData.Bytes()} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//JSON metadata of PMTiles archive
+func (c *Cluster) pmtilesMetadata(opts ExportOptions) map[string]interface{} {
+	if opts.Format != FormatMVT {
+		return map[string]interface{}{}
+	}
+	encoder := opts.MVT
+	if encoder == nil {
+		encoder = NewMVTEncoder()
+	}
+	fields := map[string]string{
+		"cluster":     "Boolean",
+		"cluster_id":  "Number",
+		"point_count": "Number",
+	}
+	if c.Supercluster {
+		fields["point_count_abbreviated"] = "Mixed"
+	}
+	return map[string]interface{}{
+		"vector_layers": []map[string]interface{}{{
+			"id":      encoder.LayerName,
+			"fields":  fields,
+			"minzoom": c.MinZoom,
+			"maxzoom": c.MaxZoom + 1,
+		}},
+	}
+}
+
+//bounding box of initial points, zeros if there are no points
+func (c *Cluster) bounds() (west, south, east, north float64) {
+	if len(c.Points) == 0 {
+		return 0, 0, 0, 0
+	}
+	west, south = math.Inf(1), math.Inf(1)
+	east, north = math.Inf(-1), math.Inf(-1)
+	for _, p := range c.Indexes[c.MaxZoom+1].Points {
+		cp := p.(*ClusterPoint)
+		coordinates := ReverseMercatorProjection(cp.X, cp.Y)
+		west = math.Min(west, coordinates.Lon)
+		south = math.Min(south, coordinates.Lat)
+		east = math.Max(east, coordinates.Lon)
+		north = math.Max(north, coordinates.Lat)
+	}
+	return west, south, east, north
+}
+
+//serialize entries to root directory no longer than rootSize,
+//if they don't fit the root directory, they are split to leaf directories
+func pmtilesDirectories(entries []pmtilesEntry, rootSize int) ([]byte, []byte) {
+	root := pmtilesDirectory(entries)
+	if len(root) <= rootSize {
+		return root, nil
+	}
+	leafSize := 4096
+	for {
+		var leaves []byte
+		var rootEntries []pmtilesEntry
+		for i := 0; i < len(entries); i += leafSize {
+			end := i + leafSize
+			if end > len(entries) {
+				end = len(entries)
+			}
+			leaf := pmtilesDirectory(entries[i:end])
+			rootEntries = append(rootEntries, pmtilesEntry{
+				TileID: entries[i].TileID,
+				Offset: uint64(len(leaves)),
+				Length: uint64(len(leaf)),
+			})
+			leaves = append(leaves, leaf...)
+		}
+		root = pmtilesDirectory(rootEntries)
+		if len(root) <= rootSize {
+			return root, leaves
+		}
+		leafSize += leafSize / 5
+	}
+}
+
+//serialize directory entries, internal compression is none
+func pmtilesDirectory(entries []pmtilesEntry) []byte {
+	result := appendVarint(nil, uint64(len(entries)))
+	lastID := uint64(0)
+	for _, e := range entries {
+		result = appendVarint(result, e.TileID-lastID)
+		lastID = e.TileID
+	}
+	for _, e := range entries {
+		result = appendVarint(result, e.RunLength)
+	}
+	for _, e := range entries {
+		result = appendVarint(result, e.Length)
+	}
+	for i, e := range entries {
+		//0 means the entry follows the previous one
+		if i > 0 && e.Offset == entries[i-1].Offset+entries[i-1].Length {
+			result = appendVarint(result, 0)
+		} else {
+			result = appendVarint(result, e.Offset+1)
+		}
+	}
+	return result
+}
+
+//PMTiles tile id: the number of tiles on lower zooms plus position of the tile on hilbert curve
+func pmtilesTileID(z, x, y int) uint64 {
+	var acc uint64
+	for tz := 0; tz < z; tz++ {
+		acc += uint64(1) << uint(2*tz)
+	}
+	n := uint64(1) << uint(z)
+	tx, ty := uint64(x), uint64(y)
+	var d uint64
+	for s := n / 2; s > 0; s /= 2 {
+		var rx, ry uint64
+		if tx&s > 0 {
+			rx = 1
+		}
+		if ty&s > 0 {
+			ry = 1
+		}
+		d += s * s * ((3 * rx) ^ ry)
+		//rotate the quadrant
+		if ry == 0 {
+			if rx == 1 {
+				tx = n - 1 - tx
+				ty = n - 1 - ty
+			}
+			tx, ty = ty, tx
+		}
+	}
+	return acc + d
+}
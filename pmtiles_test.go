@@ -0,0 +1,132 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPMTilesTileID(t *testing.T) {
+	assert.Equal(t, uint64(0), pmtilesTileID(0, 0, 0))
+	assert.Equal(t, uint64(1), pmtilesTileID(1, 0, 0))
+	assert.Equal(t, uint64(2), pmtilesTileID(1, 0, 1))
+	assert.Equal(t, uint64(3), pmtilesTileID(1, 1, 1))
+	assert.Equal(t, uint64(4), pmtilesTileID(1, 1, 0))
+	assert.Equal(t, uint64(5), pmtilesTileID(2, 0, 0))
+	assert.Equal(t, uint64(19078479), pmtilesTileID(12, 3423, 1763))
+}
+
+func TestCluster_WritePMTiles(t *testing.T) {
+	points := importData("./testdata/places.json")
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c := NewCluster()
+	c.MaxZoom = 4
+	c.ClusterPoints(geoPoints)
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.WritePMTiles(&buf, ExportOptions{}))
+	archive := buf.Bytes()
+
+	assert.Equal(t, "PMTiles", string(archive[:7]))
+	assert.Equal(t, byte(3), archive[7])
+	header := func(offset int) uint64 { return binary.LittleEndian.Uint64(archive[offset:]) }
+	rootOffset, rootLength := header(8), header(16)
+	metadataOffset, metadataLength := header(24), header(32)
+	dataOffset := header(56)
+	assert.Equal(t, uint64(pmtilesHeaderSize), rootOffset)
+	assert.Equal(t, uint64(0), header(48)) //no leaf directories
+	assert.Equal(t, byte(1), archive[99])  //MVT
+	assert.Equal(t, byte(c.MinZoom), archive[100])
+	assert.Equal(t, byte(c.MaxZoom+1), archive[101])
+
+	var metadata struct {
+		VectorLayers []struct {
+			ID string
+		} `json:"vector_layers"`
+	}
+	assert.NoError(t, json.Unmarshal(archive[metadataOffset:metadataOffset+metadataLength], &metadata))
+	assert.Equal(t, "clusters", metadata.VectorLayers[0].ID)
+
+	entries := readPMTilesDirectory(archive[rootOffset : rootOffset+rootLength])
+	assert.Equal(t, header(80), uint64(len(entries)))
+	find := func(id uint64) []byte {
+		for _, e := range entries {
+			if id >= e.TileID && id < e.TileID+e.RunLength {
+				return archive[dataOffset+e.Offset : dataOffset+e.Offset+e.Length]
+			}
+		}
+		return nil
+	}
+
+	//all non-empty tiles are in the archive
+	encoder := NewMVTEncoder()
+	addressed := 0
+	for z := c.MinZoom; z <= c.MaxZoom+1; z++ {
+		for x := 0; x < 1<<uint(z); x++ {
+			for y := 0; y < 1<<uint(z); y++ {
+				data := find(pmtilesTileID(z, x, y))
+				if len(c.GetTile(x, y, z)) == 0 {
+					assert.Nil(t, data)
+					continue
+				}
+				addressed++
+				assert.Equal(t, encoder.EncodeTile(c, x, y, z), data)
+			}
+		}
+	}
+	assert.Equal(t, header(72), uint64(addressed))
+}
+
+func TestPMTilesDirectories(t *testing.T) {
+	var entries []pmtilesEntry
+	for i := 0; i < 10000; i++ {
+		entries = append(entries, pmtilesEntry{TileID: uint64(i * 2), Offset: uint64(i * 100), Length: 100, RunLength: 1})
+	}
+	root, leaves := pmtilesDirectories(entries, 1000)
+	assert.True(t, len(root) <= 1000)
+	assert.NotEmpty(t, leaves)
+
+	var result []pmtilesEntry
+	for _, e := range readPMTilesDirectory(root) {
+		assert.Equal(t, uint64(0), e.RunLength)
+		leaf := readPMTilesDirectory(leaves[e.Offset : e.Offset+e.Length])
+		assert.Equal(t, e.TileID, leaf[0].TileID)
+		result = append(result, leaf...)
+	}
+	assert.Equal(t, entries, result)
+}
+
+func readPMTilesDirectory(b []byte) []pmtilesEntry {
+	next := func() uint64 {
+		v, n := readVarint(b)
+		b = b[n:]
+		return v
+	}
+	entries := make([]pmtilesEntry, next())
+	id := uint64(0)
+	for i := range entries {
+		id += next()
+		entries[i].TileID = id
+	}
+	for i := range entries {
+		entries[i].RunLength = next()
+	}
+	for i := range entries {
+		entries[i].Length = next()
+	}
+	for i := range entries {
+		offset := next()
+		if offset == 0 && i > 0 {
+			entries[i].Offset = entries[i-1].Offset + entries[i-1].Length
+		} else {
+			entries[i].Offset = offset - 1
+		}
+	}
+	return entries
+}
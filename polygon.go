@@ -0,0 +1,115 @@
+package cluster
+
+import (
+	"math"
+
+	"github.com/MadAppGang/kdbush"
+)
+
+// Polygon is a list of rings, the first ring is outer boundary and the others are holes.
+// Ring could be closed (the last point is equal to the first one) or not.
+type Polygon [][]GeoCoordinates
+
+// GetClustersInPolygon returns the clusters and points for zoom level, that are placed inside the polygon.
+// Polygon edges are straight lines on the map (in Mercator projection).
+// If recount is true, NumPoints, Weight, Counts and Aggregated of clusters are recalculated
+// from initial points, that are strictly inside the polygon, and clusters without such points are skipped.
+// Cluster with only one point inside is returned as that point.
+// X coordinate of returned object is Longitude and
+// Y coordinate of returned object is Latitude
+func (c *Cluster) GetClustersInPolygon(polygon Polygon, zoom int, recount bool) []ClusterPoint {
+	if len(polygon) == 0 || len(polygon[0]) == 0 {
+		return nil
+	}
+	index := c.Indexes[c.limitZoom(zoom)]
+	rings := make([][]kdbush.SimplePoint, len(polygon))
+	for i, ring := range polygon {
+		rings[i] = make([]kdbush.SimplePoint, len(ring))
+		for j := range ring {
+			rings[i][j].X, rings[i][j].Y = MercatorProjection(ring[j])
+		}
+	}
+
+	//the outer ring box
+	minX, minY, maxX, maxY := rings[0][0].X, rings[0][0].Y, rings[0][0].X, rings[0][0].Y
+	for _, p := range rings[0] {
+		minX = math.Min(minX, p.X)
+		minY = math.Min(minY, p.Y)
+		maxX = math.Max(maxX, p.X)
+		maxY = math.Max(maxY, p.Y)
+	}
+
+	var result []ClusterPoint
+	for _, id := range index.Range(minX, minY, maxX, maxY) {
+		p := index.Points[id].(*ClusterPoint)
+		x, y := p.Coordinates()
+		if !insidePolygon(rings, x, y) {
+			continue
+		}
+		cp := p.detached()
+		inside := func(leaf *ClusterPoint) bool { return insidePolygon(rings, leaf.X, leaf.Y) }
+		if recount && cp.Id >= len(c.Points) && !c.recount(&cp, inside) {
+			continue
+		}
+		coordinates := ReverseMercatorProjection(cp.X, cp.Y)
+		cp.X = coordinates.Lon
+		cp.Y = coordinates.Lat
+		result = append(result, cp)
+	}
+	return result
+}
+
+//recalculate cluster from its initial points, that are accepted,
+//if only one point is accepted, cp is replaced with that point
+//returns false if there are no accepted points
+func (c *Cluster) recount(cp *ClusterPoint, accept func(leaf *ClusterPoint) bool) bool {
+	var ids []int
+	c.appendLeaves(&ids, cp.Id, 0, 0, 0)
+	leaves := c.Indexes[c.MaxZoom+1].Points
+	cp.NumPoints = 0
+	cp.Weight = 0
+	cp.Aggregated = nil
+	var counts map[string]int
+	if cp.counts != nil {
+		counts = make(map[string]int)
+		cp.counts = &counts
+	}
+	last := 0
+	for _, id := range ids {
+		leaf := leaves[id].(*ClusterPoint)
+		if !accept(leaf) {
+			continue
+		}
+		last = id
+		if c.Aggregator != nil {
+			if cp.NumPoints == 0 {
+				cp.Aggregated = leaf.Aggregated
+			} else {
+				cp.Aggregated = c.Aggregator.Reduce(cp.Aggregated, leaf.Aggregated)
+			}
+		}
+		if counts != nil {
+			c.addCounts(counts, leaf)
+		}
+		cp.NumPoints++
+		cp.Weight += leaf.Weight
+	}
+	if cp.NumPoints == 1 {
+		*cp = leaves[last].(*ClusterPoint).detached()
+	}
+	return cp.NumPoints > 0
+}
+
+//check the point is inside the polygon rings with even-odd rule, so holes are excluded
+func insidePolygon(rings [][]kdbush.SimplePoint, x, y float64) bool {
+	inside := false
+	for _, ring := range rings {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			a, b := ring[i], ring[j]
+			if (a.Y > y) != (b.Y > y) && x < (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
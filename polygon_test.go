@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCluster_GetClustersInPolygon(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	//box with the box hole
+	polygon := Polygon{
+		{{-120, 10}, {-60, 10}, {-60, 60}, {-120, 60}, {-120, 10}},
+		{{-90, 30}, {-80, 30}, {-80, 45}, {-90, 45}},
+	}
+	inside := func(lon, lat float64) bool {
+		inHole := lon > -90 && lon < -80 && lat > 30 && lat < 45
+		return lon > -120 && lon < -60 && lat > 10 && lat < 60 && !inHole
+	}
+
+	for _, zoom := range []int{0, 2, 17} {
+		var expected []int
+		for _, cp := range c.AllClusters(zoom) {
+			if inside(cp.X, cp.Y) {
+				expected = append(expected, cp.Id)
+			}
+		}
+		var ids []int
+		for _, cp := range c.GetClustersInPolygon(polygon, zoom, false) {
+			ids = append(ids, cp.Id)
+		}
+		assert.NotEmpty(t, ids)
+		assert.ElementsMatch(t, expected, ids)
+
+		for _, cp := range c.GetClustersInPolygon(polygon, zoom, true) {
+			if cp.Id < len(geoPoints) {
+				continue
+			}
+			leaves, _ := c.GetLeaves(cp.Id, 0, 0)
+			numPoints := 0
+			for _, leaf := range leaves {
+				if inside(leaf.GetCoordinates().Lon, leaf.GetCoordinates().Lat) {
+					numPoints++
+				}
+			}
+			assert.Equal(t, numPoints, cp.NumPoints)
+			assert.Equal(t, float64(numPoints), cp.Weight)
+		}
+	}
+}
+
+func TestCluster_GetClustersInPolygonSinglePoint(t *testing.T) {
+	points := make([]GeoPoint, 2)
+	for i, lon := range []float64{10, 12} {
+		tp := &TestPoint{}
+		tp.Geometry.Coordinates = []float64{lon, 10}
+		tp.Properties.Name = "point" + string(rune('A'+i))
+		points[i] = tp
+	}
+	c := NewCluster()
+	c.ClusterPoints(points)
+	assert.Equal(t, 1, len(c.AllClusters(0)))
+
+	//the cluster is at 11, the polygon has only the second point inside
+	polygon := Polygon{{{10.5, 9}, {12.5, 9}, {12.5, 11}, {10.5, 11}}}
+	result := c.GetClustersInPolygon(polygon, 0, true)
+	assert.Equal(t, 1, len(result))
+	assert.Equal(t, 1, result[0].Id)
+	assert.Equal(t, 1, result[0].NumPoints)
+	assert.InDelta(t, 12, result[0].X, 1e-9)
+	assert.Equal(t, "pointB", c.properties(result[0])["name"])
+}
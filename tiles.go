@@ -0,0 +1,93 @@
+package cluster
+
+import (
+	"math"
+	"slices"
+)
+
+// TileCoord is the coordinates of the tile X, Y and zoom Z
+type TileCoord struct {
+	X, Y, Z int
+}
+
+// GetTiles returns points for many tiles at once, result[i] is the same set of points, as GetTile returns for tiles[i].
+// Tiles of the same zoom share one index lookup, so it's faster than separate GetTile calls for adjacent tiles.
+// Order of points inside the tile could be different from GetTile.
+func (c *Cluster) GetTiles(tiles []TileCoord) [][]ClusterPoint {
+	result := make([][]ClusterPoint, len(tiles))
+	//unique tiles grouped by zoom
+	first := make(map[TileCoord]int)
+	byZoom := make(map[int][]int)
+	for i, t := range tiles {
+		if _, ok := first[t]; ok {
+			continue
+		}
+		first[t] = i
+		byZoom[t.Z] = append(byZoom[t.Z], i)
+	}
+	for z, ids := range byZoom {
+		c.fillTiles(z, tiles, ids, result)
+	}
+	//copy results for duplicated tiles
+	for i, t := range tiles {
+		if j := first[t]; j != i {
+			result[i] = slices.Clone(result[j])
+		}
+	}
+	return result
+}
+
+//put points of the tiles with ids for zoom z to result
+func (c *Cluster) fillTiles(z int, tiles []TileCoord, ids []int, result [][]ClusterPoint) {
+	index := c.Indexes[c.limitZoom(z)]
+	z2 := float64(int(1) << uint(z))
+	p := float64(c.PointSize) / float64(c.TileSize)
+
+	//box of all tiles, in tile units
+	lookup := make(map[[2]int]int, len(ids))
+	minTX, minTY := math.Inf(1), math.Inf(1)
+	maxTX, maxTY := math.Inf(-1), math.Inf(-1)
+	for _, i := range ids {
+		t := tiles[i]
+		lookup[[2]int{t.X, t.Y}] = i
+		minTX = math.Min(minTX, float64(t.X))
+		minTY = math.Min(minTY, float64(t.Y))
+		maxTX = math.Max(maxTX, float64(t.X))
+		maxTY = math.Max(maxTY, float64(t.Y))
+	}
+	//tiles are far from each other, one lookup of their box is slower than lookups for every tile
+	if (maxTX-minTX+1)*(maxTY-minTY+1) > float64(2*len(ids)) {
+		for _, i := range ids {
+			result[i] = c.GetTile(tiles[i].X, tiles[i].Y, tiles[i].Z)
+		}
+		return
+	}
+	minX, maxX := (minTX-p)/z2, (maxTX+1+p)/z2
+	minY, maxY := (minTY-p)/z2, (maxTY+1+p)/z2
+
+	//edge tiles get points from the other side of the world, as GetTile does
+	for shift := -1.0; shift <= 1; shift++ {
+		from, to := math.Max(minX-shift, 0), math.Min(maxX-shift, 1)
+		if from > to {
+			continue
+		}
+		for _, id := range index.Range(from, minY, to, maxY) {
+			cp := index.Points[id].(*ClusterPoint)
+			//point position in the index in tile units, it chooses the tiles as GetTile does
+			x, y := cp.Coordinates()
+			px := (x + shift) * z2
+			py := y * z2
+			//all tiles, which contain the point with padding
+			for tx := math.Ceil(px - 1 - p); tx <= math.Floor(px+p); tx++ {
+				if (shift < 0 && tx != 0) || (shift > 0 && tx != z2-1) {
+					continue
+				}
+				for ty := math.Ceil(py - 1 - p); ty <= math.Floor(py+p); ty++ {
+					if i, ok := lookup[[2]int{int(tx), int(ty)}]; ok {
+						result[i] = append(result[i], c.tilePoint(cp, (cp.X+shift)*z2-tx, cp.Y*z2-ty, c.TileSize))
+					}
+				}
+			}
+		}
+	}
+}
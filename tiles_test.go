@@ -0,0 +1,41 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCluster_GetTiles(t *testing.T) {
+	points := importData("./testdata/places.json")
+	c := NewCluster()
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c.ClusterPoints(geoPoints)
+
+	var tiles []TileCoord
+	for z := 0; z <= 3; z++ {
+		for x := 0; x < 1<<uint(z); x++ {
+			for y := 0; y < 1<<uint(z); y++ {
+				tiles = append(tiles, TileCoord{x, y, z})
+			}
+		}
+	}
+	//5x5 block and duplicate
+	for x := 10; x < 15; x++ {
+		for y := 20; y < 25; y++ {
+			tiles = append(tiles, TileCoord{x, y, 5})
+		}
+	}
+	tiles = append(tiles, TileCoord{1, 1, 2})
+	//tiles far from each other
+	tiles = append(tiles, TileCoord{0, 0, 6}, TileCoord{63, 30, 6}, TileCoord{17, 23, 6})
+
+	result := c.GetTiles(tiles)
+	assert.Equal(t, len(tiles), len(result))
+	for i, tile := range tiles {
+		assert.ElementsMatch(t, c.GetTile(tile.X, tile.Y, tile.Z), result[i], "tile %v", tile)
+	}
+}
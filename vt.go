@@ -0,0 +1,65 @@
+package cluster
+
+// VTTile is the tile in geojson-vt format, the same as supercluster's getTile returns.
+// It's the format of Mapbox GL geojson source worker, so it could be served without conversion.
+type VTTile struct {
+	Features []VTFeature `json:"features"`
+}
+
+// VTFeature is geojson-vt point feature of the cluster or the single point
+// Type is always 1 (point), Geometry has one point with integer coordinates inside the tile of VTEncoder's Extent.
+// Tags are the same as properties of GeoJSON features, ID is cluster id, it's omitted for single points.
+type VTFeature struct {
+	ID       int                    `json:"id,omitempty"`
+	Type     int                    `json:"type"`
+	Geometry [][]int                `json:"geometry"`
+	Tags     map[string]interface{} `json:"tags"`
+}
+
+// VTEncoder encodes tile points to geojson-vt tile, that could be marshaled with encoding/json.
+// Extent - tile extent of geojson-vt coordinates, pixel coordinates of tile points are scaled from Cluster's TileSize to it
+type VTEncoder struct {
+	Extent int
+}
+
+// NewVTEncoder creates new VTEncoder with default parameters:
+// Extent = 8192 (the extent of Mapbox GL geojson source tiles)
+func NewVTEncoder() *VTEncoder {
+	return &VTEncoder{
+		Extent: 8192,
+	}
+}
+
+// EncodeTile encodes points of GetTile(x, y, z) result
+// Coordinates are calculated for Extent from the positions of points, so larger Extent is more precise
+func (e *VTEncoder) EncodeTile(c *Cluster, x, y, z int) VTTile {
+	return e.encode(c, c.getTile(x, y, z, false, e.Extent), 1)
+}
+
+// Encode encodes tile points with pixel coordinates, the result of GetTile, GetTiles or GetTileFiltered
+// Pixel coordinates are scaled from Cluster's TileSize to Extent, so they are not more precise than TileSize
+func (e *VTEncoder) Encode(c *Cluster, points []ClusterPoint) VTTile {
+	return e.encode(c, points, float64(e.Extent)/float64(c.TileSize))
+}
+
+//encode tile points, pixel coordinates are multiplied by scale to get coordinates for Extent
+func (e *VTEncoder) encode(c *Cluster, points []ClusterPoint, scale float64) VTTile {
+	result := VTTile{Features: make([]VTFeature, len(points))}
+	for i, cp := range points {
+		feature := VTFeature{
+			Type:     1,
+			Geometry: [][]int{{int(c.roundPixel(cp.X * scale)), int(c.roundPixel(cp.Y * scale))}},
+			Tags:     c.properties(cp),
+		}
+		if cp.NumPoints > 1 && cp.Id > 0 {
+			feature.ID = cp.Id
+		}
+		result.Features[i] = feature
+	}
+	return result
+}
+
+// GetVTTile returns the tile x, y, z in geojson-vt format with the extent 8192, see VTEncoder
+func (c *Cluster) GetVTTile(x, y, z int) VTTile {
+	return NewVTEncoder().EncodeTile(c, x, y, z)
+}
@@ -0,0 +1,80 @@
+package cluster
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type vtTestTile struct {
+	Features []struct {
+		ID       *int
+		Type     int
+		Geometry [][]int
+		Tags     map[string]interface{}
+	}
+}
+
+func TestCluster_GetVTTile(t *testing.T) {
+	points := importData("./testdata/places.json")
+	geoPoints := make([]GeoPoint, len(points))
+	for i := range points {
+		geoPoints[i] = points[i]
+	}
+	c := NewCluster()
+	c.Supercluster = true
+	c.ClusterPoints(geoPoints)
+
+	var expected vtTestTile
+	raw, err := ioutil.ReadFile("./testdata/places-z0-0-0.json")
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(raw, &expected))
+
+	//supercluster's default extent
+	encoder := NewVTEncoder()
+	encoder.Extent = 512
+	var result vtTestTile
+	raw, err = json.Marshal(encoder.EncodeTile(c, 0, 0, 0))
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(raw, &result))
+
+	assert.Equal(t, len(expected.Features), len(result.Features))
+	for i, f := range result.Features {
+		ef := expected.Features[i]
+		assert.Equal(t, 1, f.Type)
+		assert.Equal(t, ef.Geometry, f.Geometry)
+		if ef.Tags["cluster"] == true {
+			assert.NotNil(t, f.ID)
+			assert.Equal(t, float64(*f.ID), f.Tags["cluster_id"])
+			for _, tag := range []string{"cluster", "point_count", "point_count_abbreviated"} {
+				assert.Equal(t, ef.Tags[tag], f.Tags[tag])
+			}
+		} else {
+			assert.Nil(t, f.ID)
+			assert.Equal(t, ef.Tags["name"], f.Tags["name"])
+		}
+	}
+
+	//Mapbox GL extent by default, calculated from the positions of points
+	tile := c.GetVTTile(0, 0, 0)
+	positions := c.GetTileWithLatLon(0, 0, 0)
+	assert.Equal(t, len(positions), len(tile.Features))
+	for i, f := range tile.Features {
+		x, y := MercatorProjection(GeoCoordinates{Lon: positions[i].X, Lat: positions[i].Y})
+		//points from the other edge of the world are shifted by the world size
+		shift := math.Round(x - float64(f.Geometry[0][0])/8192)
+		assert.InDelta(t, (x-shift)*8192, f.Geometry[0][0], 0.5+8192*1e-9)
+		assert.InDelta(t, y*8192, f.Geometry[0][1], 0.5+8192*1e-9)
+	}
+
+	//pixels of the points are scaled to the extent
+	encoder.Extent = 1024
+	scaled := encoder.Encode(c, c.GetTile(0, 0, 0))
+	for i, f := range scaled.Features {
+		assert.Equal(t, result.Features[i].Geometry[0][0]*2, f.Geometry[0][0])
+		assert.Equal(t, result.Features[i].Geometry[0][1]*2, f.Geometry[0][1])
+	}
+}